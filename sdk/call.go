@@ -0,0 +1,175 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrNoResponders 被调用的 App 没有订阅命令主题（未运行或未连接）
+	ErrNoResponders = errors.New("no responders available for request")
+	// ErrTimeout 在超时时间内没有收到回复
+	ErrTimeout = errors.New("request timed out")
+)
+
+// RemoteError 被调用的 App 返回执行失败（CommandResult.Success 为 false）
+type RemoteError struct {
+	AppKey string        // 被调用的 App
+	Action string        // 命令动作
+	Result CommandResult // 被调用方返回的结果
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("app %s failed to handle %s: %s", e.AppKey, e.Action, e.Result.Message)
+}
+
+// CallOptions 单次调用的选项，零值字段使用 Options 中的默认值
+type CallOptions struct {
+	Timeout       time.Duration // 每次尝试等待回复的超时时间，默认 Options.CallTimeout
+	Retries       int           // 无响应（或开启 RetryOnTimeout 时超时）后的重试次数，默认 Options.CallRetries；小于 0 表示不重试
+	RetryInterval time.Duration // 第一次重试前的等待时间，之后每次翻倍，默认 Options.CallRetryInterval
+
+	// RetryOnTimeout 超时后也重试。超时时被调用方可能已经收到并执行了命令，
+	// 重试会使其再次执行，SDK 不对重复的 CommandID 去重，只应对幂等的命令开启
+	RetryOnTimeout bool
+}
+
+// callOptions 合并调用选项和默认值
+func (c *Client) callOptions(opts []CallOptions) CallOptions {
+	o := CallOptions{}
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = c.opts.CallTimeout
+	}
+	if o.Retries == 0 {
+		o.Retries = c.opts.CallRetries
+	}
+	if o.Retries < 0 {
+		o.Retries = 0
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = c.opts.CallRetryInterval
+	}
+	return o
+}
+
+// Call 调用另一个 App 的命令：向 app.<appKey>.cmd 发送请求并等待 CommandResult
+// payload 为 nil、map[string]interface{} 或可 JSON 序列化的结构体（按 json 标签转换为 map）
+// 没有响应者（ErrNoResponders）时按 CallOptions 重试；超时（ErrTimeout）时命令可能已被执行，
+// 只有开启 CallOptions.RetryOnTimeout 才重试。重试使用相同的 CommandID；
+// 被调用方返回失败时返回 *RemoteError，同时返回其结果
+func (c *Client) Call(ctx context.Context, appKey, action string, payload interface{}, opts ...CallOptions) (CommandResult, error) {
+	data, err := toPayload(payload)
+	if err != nil {
+		return CommandResult{}, fmt.Errorf("invalid payload: %w", err)
+	}
+	cmd := Command{
+		Action:    action,
+		Payload:   data,
+		CommandID: randomHex(16),
+	}
+
+	ctx, span := c.tracer.Start(ctx, "call "+appKey+" "+action,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("edge.call.app_key", appKey),
+			attribute.String("edge.command.action", action),
+			attribute.String("edge.command.id", cmd.CommandID),
+		),
+	)
+	result, err := c.call(ctx, NewTopicBuilder(appKey).Command(), cmd, c.callOptions(opts))
+	if err == nil && !result.Success {
+		err = &RemoteError{AppKey: appKey, Action: action, Result: result}
+	}
+	endSpan(span, err)
+	return result, err
+}
+
+// call 发送命令请求，无响应（或开启 RetryOnTimeout 时超时）时重试
+func (c *Client) call(ctx context.Context, subject string, cmd Command, o CallOptions) (CommandResult, error) {
+	interval := o.RetryInterval
+	for attempt := 0; ; attempt++ {
+		result, err := c.callOnce(ctx, subject, cmd, o.Timeout)
+		retryable := errors.Is(err, ErrNoResponders) || (o.RetryOnTimeout && errors.Is(err, ErrTimeout))
+		if err == nil || !retryable || attempt >= o.Retries || ctx.Err() != nil {
+			return result, err
+		}
+
+		c.logger.Debugf("Call %s on %s failed (attempt %d/%d): %v, retrying in %v",
+			cmd.Action, subject, attempt+1, o.Retries+1, err, interval)
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return CommandResult{}, fmt.Errorf("call %s on %s: %w", cmd.Action, subject, ctx.Err())
+		}
+		interval *= 2
+	}
+}
+
+// callOnce 发送一次命令请求并解码结果
+func (c *Client) callOnce(ctx context.Context, subject string, cmd Command, timeout time.Duration) (CommandResult, error) {
+	msg, err := c.request(ctx, subject, cmd, timeout)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNoResponders), errors.Is(err, nats.ErrNoResponders):
+			err = ErrNoResponders
+		case errors.Is(err, context.DeadlineExceeded), errors.Is(err, nats.ErrTimeout):
+			err = ErrTimeout
+		}
+		return CommandResult{}, fmt.Errorf("call %s on %s: %w", cmd.Action, subject, err)
+	}
+
+	var result CommandResult
+	if err := c.decodeMessage(msg, &result); err != nil {
+		return CommandResult{}, fmt.Errorf("failed to decode command result: %w", err)
+	}
+	return result, nil
+}
+
+// CallAs 调用另一个 App 的命令，并将结果的 Data 解码为 T（按 json 标签）
+func CallAs[T any](ctx context.Context, c *Client, appKey, action string, payload interface{}, opts ...CallOptions) (T, error) {
+	var out T
+	result, err := c.Call(ctx, appKey, action, payload, opts...)
+	if err != nil {
+		return out, err
+	}
+	if err := convertJSON(result.Data, &out); err != nil {
+		return out, fmt.Errorf("failed to decode result data: %w", err)
+	}
+	return out, nil
+}
+
+// toPayload 将调用参数转换为命令负载
+func toPayload(payload interface{}) (map[string]interface{}, error) {
+	switch p := payload.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return p, nil
+	}
+	var data map[string]interface{}
+	if err := convertJSON(payload, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// convertJSON 通过 JSON 编解码转换类型
+func convertJSON(in, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
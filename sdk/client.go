@@ -1,37 +1,91 @@
 package sdk
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client SDK 客户端
 type Client struct {
 	opts          Options
-	nats          *NATSClient
+	transport     Transport
 	topics        *TopicBuilder
 	startTime     time.Time
+	clock         Clock
+	codec         Codec
+	protocol      atomic.Int32 // 当前协议版本
 	mu            sync.RWMutex
 	running       bool
 	heartbeatStop chan struct{}
 	logger        *logrus.Logger
 	minLogLevel   LogLevel // 最小日志级别，只有大于等于此级别的日志才上报到 NATS
+	secrets       *secretBox
+	config        *configLayers
+	configMu      sync.Mutex // 串行化配置应用流程
+	configWatch   *configWatcher
+	// 配置同步在后台执行，同步期间再次触发时在结束后重新同步一次
+	configSyncMu      sync.Mutex
+	configSyncRunning bool
+	configSyncAgain   bool
+	// lastConfigWrite SDK 最近一次写入配置文件内容的 sha256
+	lastConfigWrite atomic.Value
+
+	connMu          sync.Mutex
+	connState       ConnState
+	connHandlers    []connStateSubscriber
+	nextConnHandler int
+	disconnectedAt  time.Time
+	lastConnErr     error
+
+	// 链路追踪，tracerProvider 为 SDK 根据 Options.Tracing 创建的 TracerProvider，Close 时关闭
+	tracer         trace.Tracer
+	propagator     propagation.TextMapPropagator
+	tracerProvider *sdktrace.TracerProvider
+
+	// 服务发现，apps 以 AppKey 为键
+	appsMu         sync.Mutex
+	apps           map[string]*AppInfo
+	appHandlers    []appChangeSubscriber
+	nextAppHandler int
+
+	// 数据流：streams 为声明的输出数据流，streamSubs 为数据流订阅（Close 时取消）
+	streamsMu  sync.Mutex
+	streams    map[string]*Stream
+	streamSubs map[*StreamSubscription]struct{}
+
+	// KV 存储，第一次调用 KV 时打开
+	kvMu sync.Mutex
+	kv   kvStore
+
+	// 对象存储，第一次调用 Objects 时打开；objectsExpiring 表示正在后台清理过期对象
+	objectsMu       sync.Mutex
+	objects         *objectStore
+	objectsExpiry   sync.Once
+	objectsExpiring atomic.Bool
 
 	// 回调函数
 	heartbeatCallback HeartbeatCallback
 	commandHandler    CommandHandler
-	configHandler     ConfigHandler
+	configHandler     ConfigMetadataHandler
+	pendingConfig     *pendingConfig // 注册配置处理函数之前已应用、尚未交给处理函数的配置
 }
 
 // NewClient 创建新的 SDK 客户端
 func NewClient(opts Options) (*Client, error) {
-	if opts.NatsURL == "" {
+	if opts.NatsURL == "" && len(opts.NATS.Servers) == 0 {
 		opts.NatsURL = "nats://127.0.0.1:4222"
 	}
 
@@ -40,19 +94,70 @@ func NewClient(opts Options) (*Client, error) {
 		opts.HeartbeatInterval = 30 * time.Second
 	}
 
+	// 设置默认配置拉取超时
+	if opts.ConfigSyncTimeout == 0 {
+		opts.ConfigSyncTimeout = 5 * time.Second
+	}
+
+	// 设置默认时钟
+	if opts.Clock == nil {
+		opts.Clock = realClock{}
+	}
+
+	// 设置默认编码
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec
+	}
+
+	// 设置默认协议版本
+	if len(opts.ProtocolVersions) == 0 {
+		opts.ProtocolVersions = append([]int(nil), DefaultProtocolVersions...)
+	}
+	if err := validateProtocolVersions(opts.ProtocolVersions); err != nil {
+		return nil, fmt.Errorf("invalid protocol options: %w", err)
+	}
+	if opts.ProtocolNegotiationTimeout == 0 {
+		opts.ProtocolNegotiationTimeout = 2 * time.Second
+	}
+	if opts.DiscoveryTTL == 0 {
+		opts.DiscoveryTTL = 3 * opts.HeartbeatInterval
+	}
+	if opts.CallTimeout == 0 {
+		opts.CallTimeout = 5 * time.Second
+	}
+	if opts.CallRetryInterval == 0 {
+		opts.CallRetryInterval = 100 * time.Millisecond
+	}
+
 	// 设置默认日志级别
 	if opts.LogLevel == "" {
 		opts.LogLevel = "Info"
 	}
 
-	// 连接 NATS
-	natsClient, err := NewNATSClient(opts.NatsURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create NATS client: %w", err)
+	// 配置文件位置、格式和权限
+	if err := resolveConfigOptions(&opts); err != nil {
+		return nil, fmt.Errorf("invalid config options: %w", err)
 	}
 
-	// 创建主题构建器
-	topics := NewTopicBuilder(opts.AppKey)
+	// 存储位置
+	if opts.KVBucket == "" {
+		opts.KVBucket = defaultBucketName(opts.AppKey)
+	}
+	if err := validateBucketName(opts.KVBucket); err != nil {
+		return nil, fmt.Errorf("invalid storage options: %w", err)
+	}
+	if opts.StorageDir == "" {
+		opts.StorageDir = filepath.Join(opts.ConfigDir, "data")
+	}
+	if opts.ObjectBucket == "" {
+		opts.ObjectBucket = defaultBucketName(opts.AppKey)
+	}
+	if err := validateBucketName(opts.ObjectBucket); err != nil {
+		return nil, fmt.Errorf("invalid storage options: %w", err)
+	}
+	if opts.ObjectChunkSize <= 0 {
+		opts.ObjectChunkSize = DefaultObjectChunkSize
+	}
 
 	// 初始化 logrus
 	logger := logrus.New()
@@ -61,21 +166,49 @@ func NewClient(opts Options) (*Client, error) {
 	})
 	logger.SetLevel(stringToLogrusLevel(opts.LogLevel))
 
+	// 未指定传输层时连接 NATS
+	transport := opts.Transport
+	if transport == nil {
+		natsOpts := opts.NATS
+		if natsOpts.Logger == nil {
+			natsOpts.Logger = logger
+		}
+		natsClient, err := NewNATSClientWithOptions(opts.NatsURL, natsOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create NATS client: %w", err)
+		}
+		transport = natsClient
+	}
+
+	// 创建主题构建器
+	topics := NewTopicBuilder(opts.AppKey)
+
 	// 设置最小日志级别
 	minLogLevel := LogLevel(opts.LogLevel)
 
 	client := &Client{
 		opts:          opts,
-		nats:          natsClient,
+		transport:     transport,
 		topics:        topics,
-		startTime:     time.Now(),
+		startTime:     opts.Clock.Now(),
+		clock:         opts.Clock,
+		codec:         opts.Codec,
 		running:       true,
 		heartbeatStop: make(chan struct{}),
 		logger:        logger,
 		minLogLevel:   minLogLevel,
+		secrets:       newSecretBox(opts.SecretKeyFile),
+		config:        newConfigLayers(),
+		streams:       make(map[string]*Stream),
+		streamSubs:    make(map[*StreamSubscription]struct{}),
 	}
 
 	// 初始化各个模块
+	if err := client.initTracing(); err != nil {
+		return nil, fmt.Errorf("failed to init tracing: %w", err)
+	}
+	client.initConnState()
+	client.initConfigLayers()
 	if err := client.initHeartbeat(); err != nil {
 		return nil, fmt.Errorf("failed to init heartbeat: %w", err)
 	}
@@ -85,9 +218,24 @@ func NewClient(opts Options) (*Client, error) {
 	if err := client.initConfig(); err != nil {
 		return nil, fmt.Errorf("failed to init config: %w", err)
 	}
+	if err := client.initDiscovery(); err != nil {
+		return nil, fmt.Errorf("failed to init discovery: %w", err)
+	}
+	client.initProtocol()
+	client.initConfigSync()
+	if opts.WatchConfig {
+		if err := client.watchConfig(); err != nil {
+			return nil, fmt.Errorf("failed to watch config: %w", err)
+		}
+	}
+
+	// 启动心跳；定时器在返回前创建，FakeClock.WaitForTickers(1) 返回时心跳定时器一定已经存在
+	go client.startHeartbeat(client.clock.NewTicker(opts.HeartbeatInterval))
 
-	// 启动心跳
-	go client.startHeartbeat(opts.HeartbeatInterval)
+	// 设置了 ObjectTTL 时清理上次运行留下的过期对象；否则在第一次打开对象存储时启动
+	if opts.ObjectTTL > 0 {
+		client.startObjectExpiry()
+	}
 
 	// 优雅关闭
 	go client.handleShutdown()
@@ -111,9 +259,26 @@ func (c *Client) OnCommand(handler CommandHandler) {
 
 // OnConfig 注册配置更新处理函数
 func (c *Client) OnConfig(handler ConfigHandler) {
+	if handler == nil {
+		c.OnConfigWithMetadata(nil)
+		return
+	}
+	c.OnConfigWithMetadata(func(cfg map[string]interface{}, _ Metadata) error {
+		return handler(cfg)
+	})
+}
+
+// OnConfigWithMetadata 注册配置更新处理函数，同时接收配置消息的元数据（消息头）
+// 与 OnConfig 互相覆盖，只有最后注册的处理函数生效
+// 注册之前已经应用的配置（如启动时同步的远程配置）会在注册后补发给处理函数
+func (c *Client) OnConfigWithMetadata(handler ConfigMetadataHandler) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	c.configHandler = handler
+	c.mu.Unlock()
+
+	if handler != nil {
+		go c.deliverPendingConfig()
+	}
 }
 
 // GetLogger 获取 logrus logger 实例
@@ -131,51 +296,65 @@ func (c *Client) SetMinLogLevel(level LogLevel) {
 // LogTrace 上报 Trace 级别日志
 func (c *Client) LogTrace(message string) {
 	c.logger.Trace(message)
-	c.log(LogLevelTrace, message)
+	c.log(context.Background(), LogLevelTrace, message)
 }
 
 // LogDebug 上报 Debug 级别日志
 func (c *Client) LogDebug(message string) {
 	c.logger.Debug(message)
-	c.log(LogLevelDebug, message)
+	c.log(context.Background(), LogLevelDebug, message)
 }
 
 // LogInfo 上报 Info 级别日志
 func (c *Client) LogInfo(message string) {
 	c.logger.Info(message)
-	c.log(LogLevelInfo, message)
+	c.log(context.Background(), LogLevelInfo, message)
 }
 
 // LogWarn 上报 Warn 级别日志
 func (c *Client) LogWarn(message string) {
 	c.logger.Warn(message)
-	c.log(LogLevelWarn, message)
+	c.log(context.Background(), LogLevelWarn, message)
 }
 
 // LogError 上报 Error 级别日志
 func (c *Client) LogError(message string) {
 	c.logger.Error(message)
-	c.log(LogLevelError, message)
+	c.log(context.Background(), LogLevelError, message)
 }
 
 // LogFatal 上报 Fatal 级别日志
 func (c *Client) LogFatal(message string) {
 	c.logger.Fatal(message)
-	c.log(LogLevelFatal, message)
+	c.log(context.Background(), LogLevelFatal, message)
 }
 
 // LogPanic 上报 Panic 级别日志
 func (c *Client) LogPanic(message string) {
 	c.logger.Panic(message)
-	c.log(LogLevelPanic, message)
+	c.log(context.Background(), LogLevelPanic, message)
+}
+
+// LogContext 上报指定级别的日志，ctx 中的追踪上下文写入消息头，日志同时作为事件记录到当前 span
+// Fatal/Panic 级别只记录和上报，不会退出或 panic
+func (c *Client) LogContext(ctx context.Context, level LogLevel, message string) {
+	c.logger.Log(stringToLogrusLevel(string(level)), message)
+	c.log(ctx, level, message)
 }
 
 // log 内部日志上报方法（只有大于等于配置级别的日志才上报到 NATS）
-func (c *Client) log(level LogLevel, message string) {
+func (c *Client) log(ctx context.Context, level LogLevel, message string) {
 	if !c.isRunning() {
 		return
 	}
 
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.AddEvent("log", trace.WithAttributes(
+			attribute.String("log.severity", string(level)),
+			attribute.String("log.message", message),
+		))
+	}
+
 	// 检查日志级别，只有大于等于配置的级别才上报
 	c.mu.RLock()
 	minLevel := c.minLogLevel
@@ -188,16 +367,21 @@ func (c *Client) log(level LogLevel, message string) {
 	logData := LogData{
 		Level:     string(level),
 		Message:   message,
-		Timestamp: time.Now().Unix(),
+		Timestamp: c.now().Unix(),
 	}
 
-	if err := c.nats.Publish(c.topics.Logs(), logData); err != nil {
+	if err := c.publish(ctx, c.topics.Logs(), logData); err != nil {
 		c.logger.Errorf("Failed to publish log to NATS: %v", err)
 	}
 }
 
 // EmitEvent 上报事件
 func (c *Client) EmitEvent(event string, data map[string]interface{}) {
+	c.EmitEventContext(context.Background(), event, data)
+}
+
+// EmitEventContext 上报事件，ctx 中的追踪上下文写入消息头
+func (c *Client) EmitEventContext(ctx context.Context, event string, data map[string]interface{}) {
 	if !c.isRunning() {
 		return
 	}
@@ -205,10 +389,10 @@ func (c *Client) EmitEvent(event string, data map[string]interface{}) {
 	eventData := EventData{
 		Event:     event,
 		Data:      data,
-		Timestamp: time.Now().Unix(),
+		Timestamp: c.now().Unix(),
 	}
 
-	if err := c.nats.Publish(c.topics.Events(), eventData); err != nil {
+	if err := c.publish(ctx, c.topics.Events(), eventData); err != nil {
 		c.logger.Errorf("Failed to publish event: %v", err)
 	}
 }
@@ -223,10 +407,10 @@ func (c *Client) ReportStatus(data map[string]interface{}) {
 		AppKey:    c.opts.AppKey,
 		Status:    "running",
 		Data:      data,
-		Timestamp: time.Now().Unix(),
+		Timestamp: c.now().Unix(),
 	}
 
-	if err := c.nats.Publish(c.topics.Status(), statusData); err != nil {
+	if err := c.publish(context.Background(), c.topics.Status(), statusData); err != nil {
 		c.logger.Errorf("Failed to publish status: %v", err)
 	}
 }
@@ -243,10 +427,20 @@ func (c *Client) Close() error {
 	c.running = false
 	close(c.heartbeatStop)
 
-	if c.nats != nil {
-		c.nats.Close()
+	if c.configWatch != nil {
+		c.configWatch.close()
+	}
+
+	c.closeStreams()
+	c.closeKV()
+
+	if c.transport != nil {
+		c.sendStoppedHeartbeat()
+		c.transport.Close()
 	}
 
+	c.shutdownTracing()
+
 	return nil
 }
 
@@ -259,7 +453,7 @@ func (c *Client) isRunning() bool {
 
 // GetUptime 获取运行时长（秒）
 func (c *Client) GetUptime() int64 {
-	return int64(time.Since(c.startTime).Seconds())
+	return int64(c.now().Sub(c.startTime).Seconds())
 }
 
 // handleShutdown 处理优雅关闭
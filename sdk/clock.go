@@ -0,0 +1,44 @@
+package sdk
+
+import "time"
+
+// Clock 时钟接口，用于心跳定时和时间戳，测试中可替换为可控时钟（见 sdktest.FakeClock）
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker 定时器接口
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// realClock 系统时钟
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+// realTicker 系统定时器
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t realTicker) Stop() {
+	t.t.Stop()
+}
+
+// now 当前时间
+func (c *Client) now() time.Time {
+	return c.clock.Now()
+}
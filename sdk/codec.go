@@ -0,0 +1,238 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// 消息头
+const (
+	HeaderContentType = "Content-Type" // 消息体编码
+	HeaderAccept      = "Accept"       // 请求方期望的回复编码
+)
+
+// 内置编码的 Content-Type
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeMsgPack  = "application/msgpack"
+	ContentTypeCBOR     = "application/cbor"
+	ContentTypeProtobuf = "application/protobuf"
+)
+
+// Codec 消息编解码器
+type Codec interface {
+	// ContentType 编码对应的 Content-Type
+	ContentType() string
+	// Marshal 编码
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal 解码
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// 内置编解码器
+var (
+	JSONCodec     Codec = jsonCodec{}
+	MsgPackCodec  Codec = msgpackCodec{}
+	CBORCodec     Codec = cborCodec{}
+	ProtobufCodec Codec = protobufCodec{}
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		ContentTypeJSON:            JSONCodec,
+		ContentTypeMsgPack:         MsgPackCodec,
+		"application/x-msgpack":    MsgPackCodec,
+		"application/vnd.msgpack":  MsgPackCodec,
+		ContentTypeCBOR:            CBORCodec,
+		ContentTypeProtobuf:        ProtobufCodec,
+		"application/x-protobuf":   ProtobufCodec,
+		"application/vnd.protobuf": ProtobufCodec,
+	}
+)
+
+// RegisterCodec 注册编解码器，收到对应 Content-Type 的消息时使用它解码
+func RegisterCodec(codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[normalizeContentType(codec.ContentType())] = codec
+}
+
+// CodecFor 根据 Content-Type 查找编解码器（忽略参数和大小写，如 "application/json; charset=utf-8"）
+func CodecFor(contentType string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[normalizeContentType(contentType)]
+	return codec, ok
+}
+
+// normalizeContentType 去掉参数并转为小写
+func normalizeContentType(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// DecodeMessage 按消息头中的 Content-Type 解码消息体，未设置 Content-Type 时按 JSON 解码
+// 协议 v2 的消息从 Envelope 的 data 字段解码
+func DecodeMessage(msg *Message, v interface{}) error {
+	codec, err := messageCodec(msg)
+	if err != nil {
+		return err
+	}
+
+	version, err := messageProtocol(msg)
+	if err != nil {
+		return err
+	}
+	if version == ProtocolV2 {
+		envelope := Envelope{Data: v}
+		return codec.Unmarshal(msg.Data, &envelope)
+	}
+	return codec.Unmarshal(msg.Data, v)
+}
+
+// messageCodec 消息头中 Content-Type 对应的编解码器，未设置时为 JSON
+func messageCodec(msg *Message) (Codec, error) {
+	contentType := msg.Header.Get(HeaderContentType)
+	if contentType == "" {
+		return JSONCodec, nil
+	}
+	codec, ok := CodecFor(contentType)
+	if !ok {
+		return nil, fmt.Errorf("unsupported content type: %s", contentType)
+	}
+	return codec, nil
+}
+
+// jsonCodec JSON 编码
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// msgpackCodec MessagePack 编码，结构体字段名沿用 json 标签
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string {
+	return ContentTypeMsgPack
+}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// cborDecMode 将 CBOR map 解码为 map[string]interface{}，与 JSON 解码结果一致
+var cborDecMode, _ = cbor.DecOptions{
+	DefaultMapType: reflect.TypeOf(map[string]interface{}(nil)),
+}.DecMode()
+
+// cborCodec CBOR 编码，结构体字段名沿用 json 标签
+type cborCodec struct{}
+
+func (cborCodec) ContentType() string {
+	return ContentTypeCBOR
+}
+
+func (cborCodec) Marshal(v interface{}) ([]byte, error) {
+	return cbor.Marshal(v)
+}
+
+func (cborCodec) Unmarshal(data []byte, v interface{}) error {
+	// CBOR 解码到 interface{} 字段时会替换而不是填充其中的指针，Envelope 的 data 需单独解码
+	if envelope, ok := v.(*Envelope); ok && envelope.Data != nil {
+		raw := struct {
+			Version   int             `json:"v"`
+			Type      string          `json:"type"`
+			ID        string          `json:"id"`
+			Timestamp int64           `json:"ts"`
+			Data      cbor.RawMessage `json:"data"`
+		}{}
+		if err := cborDecMode.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		envelope.Version, envelope.Type, envelope.ID, envelope.Timestamp = raw.Version, raw.Type, raw.ID, raw.Timestamp
+		if len(raw.Data) == 0 {
+			return nil
+		}
+		return cborDecMode.Unmarshal(raw.Data, envelope.Data)
+	}
+	return cborDecMode.Unmarshal(data, v)
+}
+
+// protobufCodec Protobuf 编码
+// proto.Message 直接编解码；SDK 的数据模型等其他值按 JSON 字段映射为 google.protobuf.Value，
+// 对端可以使用任意语言的 Protobuf 运行时解析
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return proto.Marshal(m)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	value, err := structpb.NewValue(generic)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(value)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	if m, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, m)
+	}
+
+	var value structpb.Value
+	if err := proto.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	generic, err := json.Marshal(value.AsInterface())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(generic, v)
+}
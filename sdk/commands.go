@@ -1,19 +1,17 @@
 package sdk
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"time"
 
-	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // initCommands 初始化命令处理模块
 func (c *Client) initCommands() error {
 	// 订阅命令主题
-	_, err := c.nats.Subscribe(c.topics.Command(), func(msg *nats.Msg) {
-		c.handleCommand(msg)
-	})
+	_, err := c.subscribe(c.topics.Command(), c.handleCommand)
 	if err != nil {
 		return fmt.Errorf("failed to subscribe to command topic: %w", err)
 	}
@@ -22,43 +20,97 @@ func (c *Client) initCommands() error {
 }
 
 // handleCommand 处理接收到的命令
-func (c *Client) handleCommand(msg *nats.Msg) {
+// 处理过程记录在 span 中，父 span 取自命令消息头中的追踪上下文
+func (c *Client) handleCommand(msg *Message) {
+	ctx, span := c.startMessageSpan(msg, "command")
+	defer span.End()
+
 	var cmd Command
-	if err := json.Unmarshal(msg.Data, &cmd); err != nil {
-		c.LogError(fmt.Sprintf("Failed to unmarshal command: %v", err))
+	if err := c.decodeMessage(msg, &cmd); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid command payload")
+		c.LogContext(ctx, LogLevelError, fmt.Sprintf("Failed to unmarshal command: %v", err))
 		return
 	}
+	cmd.Metadata = MetadataFromHeader(msg.Header)
+	cmd.Metadata.ctx = ctx
+	span.SetName("command " + cmd.Action)
+	span.SetAttributes(
+		attribute.String("edge.command.action", cmd.Action),
+		attribute.String("edge.command.id", cmd.CommandID),
+	)
 
-	// 执行命令处理函数
-	c.mu.RLock()
-	handler := c.commandHandler
-	c.mu.RUnlock()
-
-	var result CommandResult
-	if handler != nil {
-		result = handler(cmd)
-	} else {
-		// 默认处理
-		result = c.defaultCommandHandler(cmd)
-	}
+	result := c.runCommandHandler(ctx, cmd)
 
 	// 设置命令 ID 和时间戳
 	result.CommandID = cmd.CommandID
 	if result.Timestamp == 0 {
-		result.Timestamp = time.Now().Unix()
+		result.Timestamp = c.now().Unix()
+	}
+	if !result.Success {
+		span.SetStatus(codes.Error, result.Message)
 	}
 
 	// 如果有回复主题，发送回复（RPC 模式）
 	if msg.Reply != "" {
-		if err := c.nats.Respond(msg.Reply, result); err != nil {
-			c.LogError(fmt.Sprintf("Failed to respond to command: %v", err))
+		if err := c.respond(ctx, msg, result); err != nil {
+			c.LogContext(ctx, LogLevelError, fmt.Sprintf("Failed to respond to command: %v", err))
 		}
 	} else {
 		// 否则发布到结果主题
-		if err := c.nats.Publish(c.topics.CommandResult(), result); err != nil {
-			c.LogError(fmt.Sprintf("Failed to publish command result: %v", err))
+		if err := c.publish(ctx, c.topics.CommandResult(), result); err != nil {
+			c.LogContext(ctx, LogLevelError, fmt.Sprintf("Failed to publish command result: %v", err))
+		}
+	}
+}
+
+// runCommandHandler 在独立的 span 中执行命令处理函数
+func (c *Client) runCommandHandler(ctx context.Context, cmd Command) CommandResult {
+	ctx, span := c.tracer.Start(ctx, "handle command "+cmd.Action)
+	defer span.End()
+	cmd.Metadata.ctx = ctx
+
+	c.mu.RLock()
+	handler := c.commandHandler
+	c.mu.RUnlock()
+
+	// SDK 内置命令（sdk.*）优先处理
+	result, ok := c.builtinCommandHandler(cmd)
+	if !ok {
+		if handler != nil {
+			result = handler(cmd)
+		} else {
+			// 默认处理
+			result = c.defaultCommandHandler(cmd)
 		}
 	}
+
+	if !result.Success {
+		span.SetStatus(codes.Error, result.Message)
+	}
+	return result
+}
+
+// builtinCommandHandler SDK 内置命令处理，不是内置命令时返回 false
+func (c *Client) builtinCommandHandler(cmd Command) (CommandResult, bool) {
+	switch cmd.Action {
+	case "sdk.config.effective":
+		// 有效配置及每个值的来源，密钥已脱敏
+		sources := make(map[string]interface{})
+		for path, source := range c.ConfigSources() {
+			sources[path] = string(source)
+		}
+		return CommandResult{
+			Success: true,
+			Message: "Effective config",
+			Data: map[string]interface{}{
+				"config":  RedactConfig(c.EffectiveConfig()),
+				"sources": sources,
+			},
+		}, true
+	default:
+		return CommandResult{}, false
+	}
 }
 
 // defaultCommandHandler 默认命令处理
@@ -97,4 +149,3 @@ func (c *Client) defaultCommandHandler(cmd Command) CommandResult {
 		}
 	}
 }
-
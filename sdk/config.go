@@ -1,21 +1,88 @@
 package sdk
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 
-	"github.com/nats-io/nats.go"
-	"gopkg.in/yaml.v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// 配置文件相关环境变量，优先级高于 Options 中的设置
+const (
+	EnvConfigDir    = "EDGE_APP_CONFIG_DIR"    // 配置文件目录
+	EnvConfigFile   = "EDGE_APP_CONFIG_FILE"   // 配置文件名
+	EnvConfigFormat = "EDGE_APP_CONFIG_FORMAT" // 配置文件格式（yaml/json/toml）
+	EnvConfigMode   = "EDGE_APP_CONFIG_MODE"   // 配置文件权限（八进制，如 0600）
+)
+
+// defaultAppsDir 默认 App 根目录
+const defaultAppsDir = "/usr/local/edge/apps"
+
+// resolveConfigOptions 合并环境变量并填充配置文件相关默认值
+func resolveConfigOptions(opts *Options) error {
+	if dir := os.Getenv(EnvConfigDir); dir != "" {
+		opts.ConfigDir = dir
+	}
+	if file := os.Getenv(EnvConfigFile); file != "" {
+		opts.ConfigFile = file
+	}
+	if format := os.Getenv(EnvConfigFormat); format != "" {
+		opts.ConfigFormat = ConfigFormat(format)
+	}
+	if mode := os.Getenv(EnvConfigMode); mode != "" {
+		perm, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", EnvConfigMode, mode, err)
+		}
+		opts.ConfigFileMode = os.FileMode(perm)
+	}
+
+	if opts.ConfigDir == "" {
+		opts.ConfigDir = filepath.Join(defaultAppsDir, opts.AppKey)
+	}
+
+	// 未指定格式时根据文件扩展名推断
+	if opts.ConfigFormat == "" {
+		opts.ConfigFormat = configFormatFromPath(opts.ConfigFile)
+		if opts.ConfigFormat == "" {
+			opts.ConfigFormat = ConfigFormatYAML
+		}
+	} else {
+		format, err := parseConfigFormat(string(opts.ConfigFormat))
+		if err != nil {
+			return err
+		}
+		opts.ConfigFormat = format
+	}
+
+	if opts.ConfigFile == "" {
+		opts.ConfigFile = "config." + string(opts.ConfigFormat)
+	}
+	if opts.ConfigFileMode == 0 {
+		opts.ConfigFileMode = 0644
+	}
+
+	if keyFile := os.Getenv(EnvSecretKeyFile); keyFile != "" {
+		opts.SecretKeyFile = keyFile
+	}
+	if opts.SecretKeyFile == "" {
+		opts.SecretKeyFile = filepath.Join(opts.ConfigDir, ".secret.key")
+	}
+
+	return nil
+}
+
 // initConfig 初始化配置模块
 func (c *Client) initConfig() error {
 	// 订阅配置下发主题
-	_, err := c.nats.Subscribe(c.topics.ConfigSet(), func(msg *nats.Msg) {
-		c.handleConfigUpdate(msg)
-	})
+	_, err := c.subscribe(c.topics.ConfigSet(), c.handleConfigUpdate)
 	if err != nil {
 		return fmt.Errorf("failed to subscribe to config topic: %w", err)
 	}
@@ -24,56 +91,233 @@ func (c *Client) initConfig() error {
 }
 
 // handleConfigUpdate 处理配置更新
-func (c *Client) handleConfigUpdate(msg *nats.Msg) {
+// 如果 config.set 以 Request 方式发送，确认直接回复给请求方，否则发布到确认主题
+func (c *Client) handleConfigUpdate(msg *Message) {
+	ctx, span := c.startMessageSpan(msg, "config update")
+	defer span.End()
+
 	var configData ConfigData
-	if err := json.Unmarshal(msg.Data, &configData); err != nil {
-		c.LogError(fmt.Sprintf("Failed to unmarshal config: %v", err))
+	if err := c.decodeMessage(msg, &configData); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid config payload")
+		c.LogContext(ctx, LogLevelError, fmt.Sprintf("Failed to unmarshal config: %v", err))
+		c.sendConfigAck(ctx, msg, ConfigAck{
+			Message: "Invalid config payload",
+			Error:   &ErrorDetail{Code: ConfigErrInvalidPayload, Message: err.Error()},
+		})
 		return
 	}
+	configData.Metadata = MetadataFromHeader(msg.Header)
+	configData.Metadata.ctx = ctx
+	span.SetAttributes(
+		attribute.String("edge.config.version", configData.Version),
+		attribute.String("edge.config.correlation_id", configData.CorrelationID),
+	)
+	c.logger.Debugf("Received config update: %v", RedactConfig(configData.Config))
 
-	// 保存配置文件
-	configPath := c.getConfigPath()
-	if err := c.saveConfig(configPath, configData.Config); err != nil {
-		c.LogError(fmt.Sprintf("Failed to save config: %v", err))
+	ack := ConfigAck{
+		Version:       configData.Version,
+		CorrelationID: configData.CorrelationID,
+	}
+
+	phase, err := c.applyConfig(configData, ConfigSourceRemote)
+	ack.Phase = phase
+	span.SetAttributes(attribute.String("edge.config.phase", string(phase)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "config update failed")
+		c.LogContext(ctx, LogLevelError, fmt.Sprintf("Failed to update config: %v", err))
+		ack.Message = "Config update failed"
+		ack.Error = &ErrorDetail{Code: configErrorCode(err), Message: err.Error()}
 		// 发送失败确认
-		c.sendConfigAck(false, fmt.Sprintf("Failed to save config: %v", err))
+		c.sendConfigAck(ctx, msg, ack)
+		return
+	}
+
+	// 发送成功确认
+	ack.Success = true
+	ack.Message = "Config updated successfully"
+	c.sendConfigAck(ctx, msg, ack)
+	c.LogContext(ctx, LogLevelInfo, "Config updated successfully")
+}
+
+// pendingConfig 注册配置处理函数之前已应用的配置
+type pendingConfig struct {
+	metadata Metadata
+	source   ConfigSource
+}
+
+// deliverPendingConfig 将注册处理函数之前已应用的配置（有效配置）交给处理函数
+// 配置已保存并生效，处理函数返回错误时只记录日志，不回滚
+func (c *Client) deliverPendingConfig() {
+	// 等待正在执行的配置应用流程结束
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+
+	c.mu.Lock()
+	pending := c.pendingConfig
+	handler := c.configHandler
+	if handler != nil {
+		c.pendingConfig = nil
+	}
+	c.mu.Unlock()
+	if pending == nil || handler == nil || !c.isRunning() {
 		return
 	}
 
-	// 调用配置处理函数
+	if err := c.runConfigHandler(handler, c.EffectiveConfig(), pending.metadata, pending.source); err != nil {
+		c.LogError(fmt.Sprintf("Failed to apply config applied before OnConfig: %v", err))
+	}
+}
+
+// configStepError 配置应用某一步骤的错误，附带错误码
+type configStepError struct {
+	code string
+	err  error
+}
+
+func (e *configStepError) Error() string {
+	return e.err.Error()
+}
+
+func (e *configStepError) Unwrap() error {
+	return e.err
+}
+
+// configErrorCode 获取错误码
+func configErrorCode(err error) string {
+	var stepErr *configStepError
+	if errors.As(err, &stepErr) {
+		return stepErr.code
+	}
+	return ConfigErrApplyFailed
+}
+
+// applyConfig 配置应用流程：解密密钥、加密落盘、合并配置层、调用配置处理函数、更新日志级别
+// 远程下发（ConfigSourceRemote）和本地文件修改（ConfigSourceFile）都经过此流程
+// 返回处理结束时到达的阶段；配置处理函数失败时回滚配置文件
+func (c *Client) applyConfig(configData ConfigData, source ConfigSource) (ConfigPhase, error) {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+
+	// 解密密钥，交给配置处理函数的配置中密钥为 Secret 类型
+	config, err := c.secrets.openSecrets(configData.Config)
+	if err != nil {
+		return "", &configStepError{ConfigErrInvalidConfig, fmt.Errorf("failed to decrypt config secrets: %w", err)}
+	}
+
+	// 加密密钥后保存配置文件
+	sealed, err := c.secrets.sealSecrets(configData.Config)
+	if err != nil {
+		return "", &configStepError{ConfigErrInvalidConfig, fmt.Errorf("failed to encrypt config secrets: %w", err)}
+	}
+	phase := ConfigPhaseValidated
+
+	// 记录当前文件内容，用于回滚
+	configPath := c.getConfigPath()
+	previous, readErr := os.ReadFile(configPath)
+	hadPrevious := readErr == nil
+	previousMeta := c.loadConfigMeta()
+
+	if err := c.saveConfig(configPath, sealed); err != nil {
+		return phase, &configStepError{ConfigErrSaveFailed, fmt.Errorf("failed to save config: %w", err)}
+	}
+	meta := configMeta{
+		Version:   configData.Version,
+		Timestamp: c.configTimestamp(configData.Timestamp),
+		Edited:    source == ConfigSourceFile,
+	}
+	if err := c.saveConfigMeta(meta); err != nil {
+		return phase, &configStepError{ConfigErrSaveFailed, fmt.Errorf("failed to save config meta: %w", err)}
+	}
+	phase = ConfigPhaseSaved
+
+	// 配置文件已是最新内容；本地修改会覆盖之前的远程下发
+	updates := map[ConfigSource]map[string]interface{}{
+		ConfigSourceFile:   config,
+		ConfigSourceRemote: nil,
+	}
+	if source == ConfigSourceRemote {
+		updates[ConfigSourceRemote] = config
+	}
+	layers, effective, sources := c.config.preview(updates)
+
+	// 调用配置处理函数（传入合并后的有效配置）
 	c.mu.RLock()
 	handler := c.configHandler
 	c.mu.RUnlock()
 
 	if handler != nil {
-		if err := handler(configData.Config); err != nil {
-			c.LogError(fmt.Sprintf("Failed to apply config: %v", err))
-			c.sendConfigAck(false, fmt.Sprintf("Failed to apply config: %v", err))
-			return
+		if err := c.runConfigHandler(handler, copyConfigValue(effective).(map[string]interface{}), configData.Metadata, source); err != nil {
+			applyErr := fmt.Errorf("failed to apply config: %w", err)
+			if rollbackErr := c.rollbackConfig(configPath, previous, hadPrevious, previousMeta); rollbackErr != nil {
+				return phase, &configStepError{ConfigErrRollbackFailed, fmt.Errorf("%w (rollback failed: %v)", applyErr, rollbackErr)}
+			}
+			return ConfigPhaseRolledBack, &configStepError{ConfigErrApplyFailed, applyErr}
 		}
 	}
+	c.config.commit(layers, effective, sources)
+
+	// 还没有注册处理函数时记录下来，注册后补发；处理函数已收到最新配置时不再补发
+	c.mu.Lock()
+	if handler == nil {
+		c.pendingConfig = &pendingConfig{metadata: configData.Metadata, source: source}
+	} else {
+		c.pendingConfig = nil
+	}
+	c.mu.Unlock()
 
 	// 更新日志级别（如果配置中有）
 	// 支持 sdk.log_level 和 log_level 两种格式
-	if sdkConfig, ok := configData.Config["sdk"].(map[string]interface{}); ok {
+	if sdkConfig, ok := effective["sdk"].(map[string]interface{}); ok {
 		if logLevelStr, ok := sdkConfig["log_level"].(string); ok {
 			c.SetMinLogLevel(LogLevel(logLevelStr))
 			c.logger.SetLevel(stringToLogrusLevel(logLevelStr))
 			c.LogInfo(fmt.Sprintf("Log level updated to: %s", logLevelStr))
 		}
-	} else if logLevelStr, ok := configData.Config["log_level"].(string); ok {
+	} else if logLevelStr, ok := effective["log_level"].(string); ok {
 		// 兼容旧格式
 		c.SetMinLogLevel(LogLevel(logLevelStr))
 		c.logger.SetLevel(stringToLogrusLevel(logLevelStr))
 		c.LogInfo(fmt.Sprintf("Log level updated to: %s", logLevelStr))
 	}
 
-	// 发送成功确认
-	c.sendConfigAck(true, "Config updated successfully")
-	c.LogInfo("Config updated successfully")
+	return ConfigPhaseApplied, nil
+}
+
+// runConfigHandler 在独立的 span 中执行配置处理函数
+func (c *Client) runConfigHandler(handler ConfigMetadataHandler, cfg map[string]interface{}, meta Metadata, source ConfigSource) error {
+	ctx, span := c.tracer.Start(meta.Context(), "handle config",
+		trace.WithAttributes(attribute.String("edge.config.source", string(source))))
+	meta.ctx = ctx
+
+	err := handler(cfg, meta)
+	endSpan(span, err)
+	return err
+}
+
+// rollbackConfig 将配置文件和版本信息恢复为应用前的状态
+func (c *Client) rollbackConfig(path string, previous []byte, hadPrevious bool, previousMeta configMeta) error {
+	unlock, err := lockFile(path+".lock", true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if hadPrevious {
+		if err := writeFileAtomic(path, previous, c.opts.ConfigFileMode); err != nil {
+			return err
+		}
+		c.rememberConfigWrite(previous)
+	} else if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return c.saveConfigMeta(previousMeta)
 }
 
-// saveConfig 保存配置到文件（YAML 格式）
+// saveConfig 保存配置到文件（格式由 Options.ConfigFormat 决定）
+// 写入过程持有建议锁，并在覆盖前把当前有效的配置备份为 .bak
 func (c *Client) saveConfig(path string, config map[string]interface{}) error {
 	// 确保目录存在
 	dir := filepath.Dir(path)
@@ -81,48 +325,88 @@ func (c *Client) saveConfig(path string, config map[string]interface{}) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// 序列化为 YAML
-	data, err := yaml.Marshal(config)
+	// 按配置格式序列化
+	data, err := marshalConfig(c.opts.ConfigFormat, config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	// 写入文件（原子写入）
-	tmpPath := path + ".tmp"
-	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
+	// 防止多个进程同时写入
+	unlock, err := lockFile(path+".lock", true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// 备份当前配置（仅当其可以正常解析时，避免用损坏的文件覆盖备份）
+	if old, err := os.ReadFile(path); err == nil {
+		if _, err := c.parseConfigData(old); err == nil {
+			if err := writeFileAtomic(path+".bak", old, c.opts.ConfigFileMode); err != nil {
+				return fmt.Errorf("failed to backup config file: %w", err)
+			}
+		}
 	}
 
-	// 原子替换
-	if err := os.Rename(tmpPath, path); err != nil {
-		return fmt.Errorf("failed to rename config file: %w", err)
+	// 写入文件（原子写入 + fsync）
+	if err := writeFileAtomic(path, data, c.opts.ConfigFileMode); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
 	}
+	c.rememberConfigWrite(data)
 
 	return nil
 }
 
-// sendConfigAck 发送配置确认
-func (c *Client) sendConfigAck(success bool, message string) {
-	ack := map[string]interface{}{
-		"success":   success,
-		"message":   message,
-		"timestamp": 0, // 可以添加时间戳
+// sendConfigAck 发送配置确认，config.set 为请求时直接回复请求方
+func (c *Client) sendConfigAck(ctx context.Context, msg *Message, ack ConfigAck) {
+	ack.AppKey = c.opts.AppKey
+	if ack.Timestamp == 0 {
+		ack.Timestamp = c.now().Unix()
 	}
 
-	if err := c.nats.Publish(c.topics.ConfigAck(), ack); err != nil {
+	var err error
+	if msg.Reply != "" {
+		err = c.respond(ctx, msg, ack)
+	} else {
+		err = c.publish(ctx, c.topics.ConfigAck(), ack)
+	}
+	if err != nil {
 		c.LogError(fmt.Sprintf("Failed to send config ack: %v", err))
 	}
 }
 
 // getConfigPath 获取配置文件路径
 func (c *Client) getConfigPath() string {
-	// 默认路径
-	return fmt.Sprintf("/usr/local/edge/apps/%s/config.yaml", c.opts.AppKey)
+	return filepath.Join(c.opts.ConfigDir, c.opts.ConfigFile)
 }
 
-// LoadConfig 加载配置文件（YAML 格式）
+// ConfigPath 返回配置文件的完整路径
+func (c *Client) ConfigPath() string {
+	return c.getConfigPath()
+}
+
+// LoadConfig 加载配置文件（格式由 Options.ConfigFormat 决定）
+// 配置中的密钥会被解密为 Secret 类型
 func (c *Client) LoadConfig() (map[string]interface{}, error) {
+	config, err := c.loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err = c.secrets.openSecrets(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt config secrets: %w", err)
+	}
+
+	return config, nil
+}
+
+// loadConfigFile 读取配置文件原始内容（密钥保持加密状态）
+// 配置文件为空或无法解析时视为损坏，自动回退到 .bak 备份
+func (c *Client) loadConfigFile() (map[string]interface{}, error) {
 	configPath := c.getConfigPath()
+
+	// 读取不加锁：写入通过 rename 原子替换，不会读到写入中的文件；
+	// 对配置目录只有读权限的进程也可以读取配置
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -131,11 +415,29 @@ func (c *Client) LoadConfig() (map[string]interface{}, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config map[string]interface{}
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	config, err := c.parseConfigData(data)
+	if err == nil {
+		return config, nil
+	}
+
+	// 配置文件损坏，尝试使用备份
+	c.logger.Warnf("Config file %s is corrupted (%v), falling back to backup", configPath, err)
+	backup, backupErr := os.ReadFile(configPath + ".bak")
+	if backupErr != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	config, backupErr = c.parseConfigData(backup)
+	if backupErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w (backup: %v)", err, backupErr)
+	}
 
 	return config, nil
 }
 
+// parseConfigData 解析配置文件内容，空文件视为损坏
+func (c *Client) parseConfigData(data []byte) (map[string]interface{}, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, errors.New("config file is empty")
+	}
+	return unmarshalConfig(c.opts.ConfigFormat, data)
+}
@@ -0,0 +1,81 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormat 配置文件格式
+type ConfigFormat string
+
+const (
+	ConfigFormatYAML ConfigFormat = "yaml"
+	ConfigFormatJSON ConfigFormat = "json"
+	ConfigFormatTOML ConfigFormat = "toml"
+)
+
+// parseConfigFormat 解析配置文件格式字符串（不区分大小写，支持 yml 别名）
+func parseConfigFormat(s string) (ConfigFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yaml", "yml":
+		return ConfigFormatYAML, nil
+	case "json":
+		return ConfigFormatJSON, nil
+	case "toml":
+		return ConfigFormatTOML, nil
+	default:
+		return "", fmt.Errorf("unsupported config format: %q", s)
+	}
+}
+
+// configFormatFromPath 根据文件扩展名推断配置格式，无法识别时返回空
+func configFormatFromPath(path string) ConfigFormat {
+	format, err := parseConfigFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return ""
+	}
+	return format
+}
+
+// marshalConfig 按指定格式序列化配置
+func marshalConfig(format ConfigFormat, config map[string]interface{}) ([]byte, error) {
+	switch format {
+	case ConfigFormatJSON:
+		return json.MarshalIndent(config, "", "  ")
+	case ConfigFormatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(config); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return yaml.Marshal(config)
+	}
+}
+
+// unmarshalConfig 按指定格式反序列化配置
+func unmarshalConfig(format ConfigFormat, data []byte) (map[string]interface{}, error) {
+	var config map[string]interface{}
+	var err error
+	switch format {
+	case ConfigFormatJSON:
+		err = json.Unmarshal(data, &config)
+	case ConfigFormatTOML:
+		err = toml.Unmarshal(data, &config)
+	default:
+		err = yaml.Unmarshal(data, &config)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = make(map[string]interface{})
+	}
+	return config, nil
+}
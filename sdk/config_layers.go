@@ -0,0 +1,232 @@
+package sdk
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigSource 配置来源（配置层）
+type ConfigSource string
+
+const (
+	ConfigSourceDefault ConfigSource = "default" // Options.ConfigDefaults 中的内置默认值
+	ConfigSourceFile    ConfigSource = "file"    // 本地配置文件
+	ConfigSourceRemote  ConfigSource = "remote"  // edge-agent 下发的配置
+	ConfigSourceEnv     ConfigSource = "env"     // 环境变量（Options.ConfigEnvPrefix）
+)
+
+// configPrecedence 配置层的合并顺序，后面的层覆盖前面的层
+var configPrecedence = []ConfigSource{
+	ConfigSourceDefault,
+	ConfigSourceFile,
+	ConfigSourceRemote,
+	ConfigSourceEnv,
+}
+
+// configLayers 分层配置
+type configLayers struct {
+	mu        sync.RWMutex
+	layers    map[ConfigSource]map[string]interface{}
+	effective map[string]interface{}
+	sources   map[string]ConfigSource
+}
+
+// newConfigLayers 创建分层配置
+func newConfigLayers() *configLayers {
+	l := &configLayers{layers: make(map[ConfigSource]map[string]interface{})}
+	l.effective, l.sources = mergeConfigLayers(l.layers)
+	return l
+}
+
+// preview 计算替换部分配置层后的合并结果（不修改当前状态）
+func (l *configLayers) preview(updates map[ConfigSource]map[string]interface{}) (map[ConfigSource]map[string]interface{}, map[string]interface{}, map[string]ConfigSource) {
+	l.mu.RLock()
+	layers := make(map[ConfigSource]map[string]interface{}, len(l.layers))
+	for source, cfg := range l.layers {
+		layers[source] = cfg
+	}
+	l.mu.RUnlock()
+
+	for source, cfg := range updates {
+		if cfg == nil {
+			delete(layers, source)
+		} else {
+			layers[source] = cfg
+		}
+	}
+
+	effective, sources := mergeConfigLayers(layers)
+	return layers, effective, sources
+}
+
+// commit 提交 preview 的结果
+func (l *configLayers) commit(layers map[ConfigSource]map[string]interface{}, effective map[string]interface{}, sources map[string]ConfigSource) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.layers = layers
+	l.effective = effective
+	l.sources = sources
+}
+
+// set 替换配置层并重新合并
+func (l *configLayers) set(updates map[ConfigSource]map[string]interface{}) {
+	l.commit(l.preview(updates))
+}
+
+// mergeConfigLayers 按优先级合并配置层，并记录每个叶子值的来源
+func mergeConfigLayers(layers map[ConfigSource]map[string]interface{}) (map[string]interface{}, map[string]ConfigSource) {
+	effective := make(map[string]interface{})
+	sources := make(map[string]ConfigSource)
+	for _, source := range configPrecedence {
+		if cfg, ok := layers[source]; ok {
+			mergeConfigInto(effective, cfg, "", source, sources)
+		}
+	}
+	return effective, sources
+}
+
+// mergeConfigInto 深度合并：map 递归合并，其他值（包括数组）整体覆盖
+func mergeConfigInto(dst, src map[string]interface{}, prefix string, source ConfigSource, sources map[string]ConfigSource) {
+	for k, v := range src {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+
+		if srcMap, ok := v.(map[string]interface{}); ok {
+			dstMap, ok := dst[k].(map[string]interface{})
+			if !ok {
+				// 覆盖非 map 值时清除其来源记录
+				deleteConfigSources(sources, path)
+				dstMap = make(map[string]interface{})
+				dst[k] = dstMap
+			}
+			mergeConfigInto(dstMap, srcMap, path, source, sources)
+			continue
+		}
+
+		deleteConfigSources(sources, path)
+		dst[k] = copyConfigValue(v)
+		sources[path] = source
+	}
+}
+
+// deleteConfigSources 删除 path 及其子路径的来源记录
+func deleteConfigSources(sources map[string]ConfigSource, path string) {
+	for key := range sources {
+		if key == path || strings.HasPrefix(key, path+".") {
+			delete(sources, key)
+		}
+	}
+}
+
+// copyConfigValue 深拷贝配置值
+func copyConfigValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = copyConfigValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = copyConfigValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// envConfig 从环境变量构建配置层
+// 例如前缀为 "APP_" 时，APP_DB__HOST=10.0.0.5 对应 db.host，APP_LOG_LEVEL=Debug 对应 log_level
+// 值按 YAML 标量解析，因此 "true"、"10"、"1.5" 会被识别为布尔值和数字
+func envConfig(prefix string) map[string]interface{} {
+	if prefix == "" {
+		return nil
+	}
+
+	environ := os.Environ()
+	sort.Strings(environ)
+
+	config := make(map[string]interface{})
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, prefix) || key == prefix {
+			continue
+		}
+
+		parts := strings.Split(strings.ToLower(strings.TrimPrefix(key, prefix)), "__")
+		current := config
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := current[part].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				current[part] = next
+			}
+			current = next
+		}
+		current[parts[len(parts)-1]] = parseEnvValue(value)
+	}
+
+	if len(config) == 0 {
+		return nil
+	}
+	return config
+}
+
+// parseEnvValue 将环境变量值解析为 YAML 标量，解析失败时保留字符串
+func parseEnvValue(value string) interface{} {
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil {
+		return value
+	}
+	switch parsed.(type) {
+	case bool, int, float64:
+		return parsed
+	default:
+		return value
+	}
+}
+
+// initConfigLayers 加载内置默认值、本地配置文件和环境变量配置层
+func (c *Client) initConfigLayers() {
+	updates := map[ConfigSource]map[string]interface{}{
+		ConfigSourceDefault: c.opts.ConfigDefaults,
+		ConfigSourceEnv:     envConfig(c.opts.ConfigEnvPrefix),
+	}
+
+	if config, err := c.LoadConfig(); err != nil {
+		c.logger.Warnf("Failed to load config file: %v", err)
+	} else {
+		updates[ConfigSourceFile] = config
+	}
+
+	c.config.set(updates)
+}
+
+// EffectiveConfig 返回合并后的有效配置（副本）
+// 合并优先级从低到高：内置默认值 < 本地配置文件 < 远程下发 < 环境变量
+func (c *Client) EffectiveConfig() map[string]interface{} {
+	c.config.mu.RLock()
+	defer c.config.mu.RUnlock()
+	return copyConfigValue(c.config.effective).(map[string]interface{})
+}
+
+// ConfigSources 返回有效配置中每个值（点分路径）的来源
+func (c *Client) ConfigSources() map[string]ConfigSource {
+	c.config.mu.RLock()
+	defer c.config.mu.RUnlock()
+
+	sources := make(map[string]ConfigSource, len(c.config.sources))
+	for k, v := range c.config.sources {
+		sources[k] = v
+	}
+	return sources
+}
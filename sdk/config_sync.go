@@ -0,0 +1,238 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// configMeta 本地配置的版本信息，保存在 <配置文件>.meta 中
+type configMeta struct {
+	Version   string `json:"version,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+	Edited    bool   `json:"edited,omitempty"` // 本地修改的配置（WatchConfig），没有版本号，按时间戳与远程比较
+}
+
+// metaPath 配置版本信息文件路径
+func (c *Client) metaPath() string {
+	return c.getConfigPath() + ".meta"
+}
+
+// loadConfigMeta 读取本地配置版本信息，不存在时返回零值
+func (c *Client) loadConfigMeta() configMeta {
+	var meta configMeta
+	data, err := os.ReadFile(c.metaPath())
+	if err != nil {
+		return meta
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		c.logger.Warnf("Failed to parse config meta: %v", err)
+	}
+	return meta
+}
+
+// saveConfigMeta 保存本地配置版本信息
+func (c *Client) saveConfigMeta(meta configMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config meta: %w", err)
+	}
+	return writeFileAtomic(c.metaPath(), data, c.opts.ConfigFileMode)
+}
+
+// initConfigSync 启动时在后台拉取配置，并在重连后重新同步
+// 同步不阻塞 NewClient；在注册 OnConfig 之前应用的配置会在注册后补发给处理函数
+func (c *Client) initConfigSync() {
+	if c.opts.DisableConfigSync {
+		return
+	}
+
+	if _, ok := c.transport.(ConnStateReporter); ok {
+		// 连接成功（包括以断开状态启动后的首次连接和重连）后同步，不占用传输层的重连回调
+		c.OnConnStateChange(func(change ConnStateChange) {
+			if change.State == ConnStateConnected {
+				c.triggerConfigSync()
+			}
+		})
+	} else {
+		// 传输层不上报连接状态时只能通过重连回调同步，会覆盖传输层上已设置的重连回调
+		c.transport.SetReconnectHandler(c.triggerConfigSync)
+	}
+
+	if c.transport.IsConnected() {
+		c.triggerConfigSync()
+	}
+}
+
+// triggerConfigSync 在后台同步配置；正在同步时不会并发执行，而是在结束后再同步一次
+func (c *Client) triggerConfigSync() {
+	c.configSyncMu.Lock()
+	if c.configSyncRunning {
+		c.configSyncAgain = true
+		c.configSyncMu.Unlock()
+		return
+	}
+	c.configSyncRunning = true
+	c.configSyncMu.Unlock()
+
+	go func() {
+		for {
+			if err := c.SyncConfig(); err != nil {
+				c.logger.Warnf("Config sync failed: %v", err)
+			}
+
+			c.configSyncMu.Lock()
+			if !c.configSyncAgain {
+				c.configSyncRunning = false
+				c.configSyncMu.Unlock()
+				return
+			}
+			c.configSyncAgain = false
+			c.configSyncMu.Unlock()
+		}
+	}()
+}
+
+// SyncConfig 向 edge-agent 请求权威配置（config.get），与本地配置比较版本：
+// 远程较新时通过正常的配置流程应用；本地较新时将本地配置上报给 edge-agent
+func (c *Client) SyncConfig() error {
+	if !c.isRunning() {
+		return nil
+	}
+
+	ctx, span := c.tracer.Start(context.Background(), "config sync", trace.WithSpanKind(trace.SpanKindClient))
+	err := c.syncConfig(ctx)
+	endSpan(span, err)
+	return err
+}
+
+// syncConfig 配置同步流程
+func (c *Client) syncConfig(ctx context.Context) error {
+	meta := c.loadConfigMeta()
+	req := ConfigRequest{
+		AppKey:    c.opts.AppKey,
+		Version:   meta.Version,
+		Timestamp: meta.Timestamp,
+	}
+
+	msg, err := c.request(ctx, c.topics.ConfigGet(), req, c.opts.ConfigSyncTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to request config: %w", err)
+	}
+
+	var remote ConfigData
+	if err := c.decodeMessage(msg, &remote); err != nil {
+		return fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	remote.Metadata = MetadataFromHeader(msg.Header)
+	remote.Metadata.ctx = ctx
+	if remote.Config == nil {
+		// edge-agent 没有该 App 的配置
+		return nil
+	}
+
+	remoteMeta := configMeta{Version: remote.Version, Timestamp: remote.Timestamp}
+	if !isNewerConfig(remoteMeta, meta) {
+		if isNewerConfig(meta, remoteMeta) {
+			c.reportLocalConfig(ctx, meta)
+		}
+		return nil
+	}
+
+	c.logger.Infof("Applying newer config from edge-agent (version %q, local %q)", remote.Version, meta.Version)
+	if _, err := c.applyConfig(remote, ConfigSourceRemote); err != nil {
+		return err
+	}
+	c.LogContext(ctx, LogLevelInfo, "Config synced from edge-agent")
+
+	return nil
+}
+
+// reportLocalConfig 将本地配置上报给 edge-agent
+func (c *Client) reportLocalConfig(ctx context.Context, meta configMeta) {
+	config, err := c.loadConfigFile()
+	if err != nil {
+		c.LogError(fmt.Sprintf("Failed to load local config: %v", err))
+		return
+	}
+	report, err := c.secrets.exportSecrets(config)
+	if err != nil {
+		c.LogError(fmt.Sprintf("Failed to export config secrets: %v", err))
+		return
+	}
+
+	configData := ConfigData{
+		Config:    report,
+		Version:   meta.Version,
+		Timestamp: meta.Timestamp,
+	}
+	if err := c.publish(ctx, c.topics.ConfigReport(), configData); err != nil {
+		c.LogError(fmt.Sprintf("Failed to report local config: %v", err))
+	}
+}
+
+// isNewerConfig 判断配置 a 是否比 b 新：
+//   - b 没有任何版本信息（如新设备上还没有 .meta）时，a 只要有版本号或时间戳就较新
+//   - 双方都有版本号时按版本号比较
+//   - 只有一方有版本号时，有版本号的一方较新；另一方是本地修改的配置时除外，按时间戳比较
+//   - 其余情况按时间戳比较
+func isNewerConfig(a, b configMeta) bool {
+	if b == (configMeta{}) {
+		return a.Version != "" || a.Timestamp > 0
+	}
+	switch {
+	case a.Version != "" && b.Version != "":
+		return compareVersions(a.Version, b.Version) > 0
+	case a.Version != "" && !b.Edited:
+		return true
+	case b.Version != "" && !a.Edited:
+		return false
+	}
+	return a.Timestamp > b.Timestamp
+}
+
+// compareVersions 比较点分版本号（如 "12"、"1.2.10"），数字段按数值比较，其余按字符串比较；
+// 缺少的段按 0 处理（"1.2" 与 "1.2.0" 相同）
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+
+		xn, xerr := strconv.ParseInt(x, 10, 64)
+		yn, yerr := strconv.ParseInt(y, 10, 64)
+		switch {
+		case xerr == nil && yerr == nil:
+			if xn != yn {
+				if xn > yn {
+					return 1
+				}
+				return -1
+			}
+		case x != y:
+			return strings.Compare(x, y)
+		}
+	}
+
+	return 0
+}
+
+// configTimestamp 配置时间戳，未设置时取当前时间
+func (c *Client) configTimestamp(ts int64) int64 {
+	if ts == 0 {
+		return c.now().Unix()
+	}
+	return ts
+}
@@ -0,0 +1,64 @@
+package sdk
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.10", "1.2.9", 1},
+		{"1.2.9", "1.2.10", -1},
+		{"1.2.10", "1.2.10", 0},
+		{"v2", "10", -1},
+		{"10", "v2", 1},
+		{"v1.2", "1.2", 0},
+		{"12", "9", 1},
+		{"1.2", "1.2.1", -1},
+		{"1.2", "1.2.0", 0},
+		{"1.2.0", "1.2", 0},
+		{"v1", "1.0.0", 0},
+		{"1.2.0.1", "1.2", 1},
+		{"1.10", "1.9", 1},
+		{"beta", "alpha", 1},
+		{"", "", 0},
+		{"1", "", 1},
+		{"", "1", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIsNewerConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		remote, local configMeta
+		want          bool
+	}{
+		{"fresh device, version only", configMeta{Version: "3"}, configMeta{}, true},
+		{"fresh device, timestamp only", configMeta{Timestamp: 100}, configMeta{}, true},
+		{"fresh device, empty remote", configMeta{}, configMeta{}, false},
+		{"newer version", configMeta{Version: "1.2.10", Timestamp: 1}, configMeta{Version: "1.2.9", Timestamp: 2}, true},
+		{"older version", configMeta{Version: "1.2.9", Timestamp: 2}, configMeta{Version: "1.2.10", Timestamp: 1}, false},
+		{"same version", configMeta{Version: "2", Timestamp: 200}, configMeta{Version: "2", Timestamp: 100}, false},
+		{"numeric version", configMeta{Version: "10"}, configMeta{Version: "v2", Timestamp: 100}, true},
+		{"remote versioned, local unversioned", configMeta{Version: "3"}, configMeta{Timestamp: 100}, true},
+		{"remote unversioned, local versioned", configMeta{Timestamp: 200}, configMeta{Version: "3", Timestamp: 100}, false},
+		{"remote versioned, local edit newer", configMeta{Version: "3", Timestamp: 100}, configMeta{Timestamp: 200, Edited: true}, false},
+		{"remote versioned, local edit older", configMeta{Version: "3", Timestamp: 300}, configMeta{Timestamp: 200, Edited: true}, true},
+		{"local edit reported over older versioned", configMeta{Timestamp: 200, Edited: true}, configMeta{Version: "3", Timestamp: 100}, true},
+		{"newer timestamp", configMeta{Timestamp: 200}, configMeta{Timestamp: 100}, true},
+		{"older timestamp", configMeta{Timestamp: 100}, configMeta{Timestamp: 200}, false},
+		{"same timestamp", configMeta{Timestamp: 100}, configMeta{Timestamp: 100}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNewerConfig(tt.remote, tt.local); got != tt.want {
+				t.Errorf("isNewerConfig(%+v, %+v) = %v, want %v", tt.remote, tt.local, got, tt.want)
+			}
+		})
+	}
+}
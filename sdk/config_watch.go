@@ -0,0 +1,157 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// configWatchDebounce 文件修改事件的合并窗口（编辑器保存时通常会触发多次事件）
+const configWatchDebounce = 500 * time.Millisecond
+
+// configWatcher 配置文件监听器
+type configWatcher struct {
+	watcher *fsnotify.Watcher
+	mu      sync.Mutex
+	timer   *time.Timer
+}
+
+// watchConfig 启动配置文件监听
+// 监听的是配置文件所在目录，因为原子写入（rename）会替换文件的 inode
+func (c *Client) watchConfig() error {
+	dir := c.opts.ConfigDir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	// 以当前文件内容为基准，避免启动时误判为本地修改
+	if data, err := os.ReadFile(c.getConfigPath()); err == nil {
+		c.rememberConfigWrite(data)
+	}
+
+	c.configWatch = &configWatcher{watcher: watcher}
+	go c.runConfigWatcher(c.configWatch)
+
+	return nil
+}
+
+// runConfigWatcher 处理文件事件，直到监听器关闭
+func (c *Client) runConfigWatcher(w *configWatcher) {
+	configPath := filepath.Clean(c.getConfigPath())
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != configPath {
+				continue
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			w.debounce(c.handleLocalConfigChange)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			c.logger.Errorf("Config watcher error: %v", err)
+		}
+	}
+}
+
+// debounce 在合并窗口结束后执行 fn
+func (w *configWatcher) debounce(fn func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(configWatchDebounce, fn)
+}
+
+// close 停止监听
+func (w *configWatcher) close() {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+
+	w.watcher.Close()
+}
+
+// rememberConfigWrite 记录 SDK 最近一次写入的配置内容，用于忽略自身写入触发的文件事件
+func (c *Client) rememberConfigWrite(data []byte) {
+	c.lastConfigWrite.Store(sha256.Sum256(data))
+}
+
+// isOwnConfigWrite 判断文件内容是否为 SDK 最近一次写入的内容
+func (c *Client) isOwnConfigWrite(data []byte) bool {
+	last, ok := c.lastConfigWrite.Load().([sha256.Size]byte)
+	return ok && last == sha256.Sum256(data)
+}
+
+// handleLocalConfigChange 处理配置文件的本地修改：校验、应用并同步到 edge-agent
+func (c *Client) handleLocalConfigChange() {
+	if !c.isRunning() {
+		return
+	}
+
+	data, err := os.ReadFile(c.getConfigPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			c.LogError(fmt.Sprintf("Failed to read local config change: %v", err))
+		}
+		return
+	}
+	if c.isOwnConfigWrite(data) {
+		return
+	}
+
+	// 校验：无法解析的修改不应用，保留当前配置
+	config, err := c.parseConfigData(data)
+	if err != nil {
+		c.LogError(fmt.Sprintf("Ignoring invalid local config change: %v", err))
+		return
+	}
+	c.logger.Debugf("Local config change detected: %v", RedactConfig(config))
+
+	configData := ConfigData{
+		Config:    config,
+		Timestamp: c.now().Unix(),
+	}
+	if _, err := c.applyConfig(configData, ConfigSourceFile); err != nil {
+		c.LogError(fmt.Sprintf("Failed to apply local config change: %v", err))
+		return
+	}
+	c.LogInfo("Local config change applied")
+
+	// 同步到 edge-agent，密钥以 $secret 标记的明文上报，与下发格式一致
+	report, err := c.secrets.exportSecrets(config)
+	if err != nil {
+		c.LogError(fmt.Sprintf("Failed to export config secrets: %v", err))
+		return
+	}
+	configData.Config = report
+	if err := c.publish(context.Background(), c.topics.ConfigReport(), configData); err != nil {
+		c.LogError(fmt.Sprintf("Failed to report local config: %v", err))
+	}
+}
@@ -0,0 +1,196 @@
+package sdk
+
+import (
+	"time"
+)
+
+// ConnState 连接状态
+type ConnState string
+
+const (
+	ConnStateConnected    ConnState = "connected"    // 已连接
+	ConnStateDisconnected ConnState = "disconnected" // 连接断开
+	ConnStateReconnecting ConnState = "reconnecting" // 正在重连
+	ConnStateClosed       ConnState = "closed"       // 已关闭，不再重连
+)
+
+// ConnStats 连接统计
+type ConnStats struct {
+	InMsgs     uint64 `json:"in_msgs"`
+	OutMsgs    uint64 `json:"out_msgs"`
+	InBytes    uint64 `json:"in_bytes"`
+	OutBytes   uint64 `json:"out_bytes"`
+	Reconnects uint64 `json:"reconnects"`
+}
+
+// ConnStateChange 连接状态变化
+type ConnStateChange struct {
+	State     ConnState // 新状态
+	Previous  ConnState // 之前的状态
+	Err       error     // 断开原因（如果有）
+	Timestamp time.Time
+}
+
+// ConnStateHandler 连接状态变化回调
+type ConnStateHandler func(change ConnStateChange)
+
+// ConnStateReporter 可选接口，传输层实现后 Client 可以获取连接状态和统计信息
+type ConnStateReporter interface {
+	// ConnState 当前连接状态
+	ConnState() ConnState
+	// SetConnStateHandler 设置连接状态变化回调
+	SetConnStateHandler(handler func(state ConnState, err error))
+	// Stats 连接统计
+	Stats() ConnStats
+}
+
+// connStateSubscriber 连接状态订阅者
+type connStateSubscriber struct {
+	id      int
+	handler ConnStateHandler
+}
+
+// EventConnectionRestored 重连成功后 SDK 在事件主题上发布的事件
+// 数据：disconnected_at（断开时间戳）、downtime_ms（断开时长）、error（断开原因）、reconnects（累计重连次数）
+const EventConnectionRestored = "sdk.connection.restored"
+
+// initConnState 订阅传输层的连接状态变化
+func (c *Client) initConnState() {
+	reporter, ok := c.transport.(ConnStateReporter)
+	if !ok {
+		return
+	}
+
+	c.connMu.Lock()
+	c.connState = reporter.ConnState()
+	c.connMu.Unlock()
+
+	reporter.SetConnStateHandler(c.handleConnState)
+}
+
+// handleConnState 处理传输层上报的连接状态变化：记录日志、通知订阅者、重连后发布事件
+func (c *Client) handleConnState(state ConnState, err error) {
+	now := c.now()
+
+	c.connMu.Lock()
+	previous := c.connState
+	if state == previous {
+		c.connMu.Unlock()
+		return
+	}
+	c.connState = state
+
+	var downtime time.Duration
+	var disconnectedAt time.Time
+	var lastErr error
+	switch state {
+	case ConnStateDisconnected, ConnStateReconnecting:
+		if c.disconnectedAt.IsZero() {
+			c.disconnectedAt = now
+		}
+		if err != nil {
+			c.lastConnErr = err
+		}
+	case ConnStateConnected:
+		disconnectedAt = c.disconnectedAt
+		lastErr = c.lastConnErr
+		if !disconnectedAt.IsZero() {
+			downtime = now.Sub(disconnectedAt)
+		}
+		c.disconnectedAt = time.Time{}
+		c.lastConnErr = nil
+	}
+
+	handlers := make([]ConnStateHandler, 0, len(c.connHandlers))
+	for _, h := range c.connHandlers {
+		handlers = append(handlers, h.handler)
+	}
+	c.connMu.Unlock()
+
+	switch state {
+	case ConnStateDisconnected:
+		if err != nil {
+			c.logger.Warnf("Connection lost: %v", err)
+		} else {
+			c.logger.Warn("Connection lost")
+		}
+	case ConnStateReconnecting:
+		c.logger.Info("Reconnecting...")
+	case ConnStateConnected:
+		if !disconnectedAt.IsZero() {
+			c.logger.Infof("Connection restored after %s", downtime.Round(time.Millisecond))
+		} else {
+			c.logger.Info("Connected")
+		}
+	case ConnStateClosed:
+		c.logger.Info("Connection closed")
+	}
+
+	change := ConnStateChange{State: state, Previous: previous, Err: err, Timestamp: now}
+	for _, handler := range handlers {
+		handler(change)
+	}
+
+	// 断开期间的状态变化无法发送，重连后补发一条汇总事件
+	if state == ConnStateConnected && !disconnectedAt.IsZero() {
+		data := map[string]interface{}{
+			"disconnected_at": disconnectedAt.Unix(),
+			"downtime_ms":     downtime.Milliseconds(),
+			"reconnects":      c.ConnStats().Reconnects,
+		}
+		if lastErr != nil {
+			data["error"] = lastErr.Error()
+		}
+		// 在独立 goroutine 中发布，避免阻塞传输层的回调
+		go c.EmitEvent(EventConnectionRestored, data)
+	}
+}
+
+// ConnState 返回当前连接状态
+func (c *Client) ConnState() ConnState {
+	if _, ok := c.transport.(ConnStateReporter); ok {
+		c.connMu.Lock()
+		defer c.connMu.Unlock()
+		return c.connState
+	}
+
+	// 传输层不上报状态时根据连接情况推断
+	switch {
+	case !c.isRunning():
+		return ConnStateClosed
+	case c.transport.IsConnected():
+		return ConnStateConnected
+	default:
+		return ConnStateDisconnected
+	}
+}
+
+// OnConnStateChange 订阅连接状态变化，返回取消订阅的函数
+// 回调在传输层的回调 goroutine 中同步执行，不应长时间阻塞
+func (c *Client) OnConnStateChange(handler ConnStateHandler) (cancel func()) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	c.nextConnHandler++
+	id := c.nextConnHandler
+	c.connHandlers = append(c.connHandlers, connStateSubscriber{id: id, handler: handler})
+
+	return func() {
+		c.connMu.Lock()
+		defer c.connMu.Unlock()
+		for i, h := range c.connHandlers {
+			if h.id == id {
+				c.connHandlers = append(c.connHandlers[:i:i], c.connHandlers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// ConnStats 返回连接统计，传输层不支持时返回零值
+func (c *Client) ConnStats() ConnStats {
+	if reporter, ok := c.transport.(ConnStateReporter); ok {
+		return reporter.Stats()
+	}
+	return ConnStats{}
+}
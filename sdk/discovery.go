@@ -0,0 +1,251 @@
+package sdk
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AppInfo 服务发现中的 App 信息，来自该 App 最近一次心跳
+type AppInfo struct {
+	AppKey    string                 // App 标识
+	Version   string                 // App 版本
+	Status    string                 // 心跳中的状态（running、error 等）
+	Metrics   map[string]interface{} // 心跳中的指标
+	Streams   []string               // 声明的输出数据流
+	FirstSeen time.Time              // 第一次收到心跳的时间（本地时钟）
+	LastSeen  time.Time              // 最近一次收到心跳的时间（本地时钟）
+}
+
+// AppChangeType App 变化类型
+type AppChangeType string
+
+const (
+	AppJoined  AppChangeType = "joined"  // 收到新 App 的心跳
+	AppUpdated AppChangeType = "updated" // App 的版本、状态或声明的数据流变化
+	AppLeft    AppChangeType = "left"    // App 发送 stopped 心跳或超过 DiscoveryTTL 未发送心跳
+)
+
+// AppChange App 变化通知
+type AppChange struct {
+	Type     AppChangeType
+	App      AppInfo // 变化后的 App 信息（AppLeft 时为最后一次心跳的信息）
+	Previous AppInfo // 变化前的 App 信息（仅 AppUpdated）
+}
+
+// AppChangeHandler App 变化回调
+type AppChangeHandler func(change AppChange)
+
+// appChangeSubscriber App 变化订阅者
+type appChangeSubscriber struct {
+	id      int
+	handler AppChangeHandler
+}
+
+// heartbeatStatusStopped App 关闭时发送的心跳状态
+const heartbeatStatusStopped = "stopped"
+
+// initDiscovery 订阅所有 App 的心跳
+// NATS 通配符只匹配一个 token，AppKey 为 "camera" 或 "app.camera" 形式时心跳主题的层数不同，分别订阅；
+// app.*.*.heartbeat 同时匹配名为 heartbeat 的数据流（app.<app_key>.data.heartbeat），由 handleAppHeartbeat 跳过
+func (c *Client) initDiscovery() error {
+	c.apps = map[string]*AppInfo{}
+	if c.opts.DisableDiscovery {
+		return nil
+	}
+
+	for _, subject := range []string{NewTopicBuilder("*").Heartbeat(), NewTopicBuilder("*.*").Heartbeat()} {
+		if _, err := c.subscribe(subject, c.handleAppHeartbeat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleAppHeartbeat 根据收到的心跳更新 App 列表
+func (c *Client) handleAppHeartbeat(msg *Message) {
+	if isStreamSubject(msg.Subject) {
+		return
+	}
+
+	var heartbeat HeartbeatData
+	if err := c.decodeMessage(msg, &heartbeat); err != nil {
+		c.logger.Debugf("Ignoring invalid heartbeat on %s: %v", msg.Subject, err)
+		return
+	}
+	if heartbeat.AppKey == "" || NewTopicBuilder(heartbeat.AppKey).Heartbeat() != msg.Subject {
+		return
+	}
+
+	now := c.now()
+	c.appsMu.Lock()
+	existing, ok := c.apps[heartbeat.AppKey]
+
+	var change AppChange
+	switch {
+	case heartbeat.Status == heartbeatStatusStopped:
+		if !ok {
+			c.appsMu.Unlock()
+			return
+		}
+		delete(c.apps, heartbeat.AppKey)
+		existing.Status = heartbeat.Status
+		existing.LastSeen = now
+		change = AppChange{Type: AppLeft, App: *existing}
+	case !ok:
+		info := &AppInfo{
+			AppKey:    heartbeat.AppKey,
+			Version:   heartbeat.Version,
+			Status:    heartbeat.Status,
+			Metrics:   heartbeat.Metrics,
+			Streams:   heartbeat.Streams,
+			FirstSeen: now,
+			LastSeen:  now,
+		}
+		c.apps[heartbeat.AppKey] = info
+		change = AppChange{Type: AppJoined, App: *info}
+	default:
+		previous := *existing
+		existing.Version = heartbeat.Version
+		existing.Status = heartbeat.Status
+		existing.Metrics = heartbeat.Metrics
+		existing.Streams = heartbeat.Streams
+		existing.LastSeen = now
+		if previous.Version == existing.Version && previous.Status == existing.Status &&
+			slices.Equal(previous.Streams, existing.Streams) {
+			c.appsMu.Unlock()
+			return
+		}
+		change = AppChange{Type: AppUpdated, App: *existing, Previous: previous}
+	}
+	handlers := c.appChangeHandlers()
+	c.appsMu.Unlock()
+
+	c.notifyAppChange(handlers, change)
+}
+
+// isStreamSubject 主题是否为数据流主题 app.<app_key>.data.<stream>（app_key 为单个 token）
+// 最后一段为 data 的 AppKey（如 "x.data"）的心跳与之无法区分，不会被发现
+func isStreamSubject(subject string) bool {
+	tokens := strings.Split(subject, ".")
+	return len(tokens) == 4 && tokens[0] == "app" && tokens[2] == "data"
+}
+
+// expireApps 移除超过 DiscoveryTTL 未发送心跳的 App，随心跳定时器执行
+// 自身在运行期间始终在线，不会过期
+func (c *Client) expireApps() {
+	if c.opts.DisableDiscovery {
+		return
+	}
+
+	now := c.now()
+	var changes []AppChange
+	c.appsMu.Lock()
+	for key, info := range c.apps {
+		if key != c.opts.AppKey && now.Sub(info.LastSeen) > c.opts.DiscoveryTTL {
+			delete(c.apps, key)
+			changes = append(changes, AppChange{Type: AppLeft, App: *info})
+		}
+	}
+	handlers := c.appChangeHandlers()
+	c.appsMu.Unlock()
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].App.AppKey < changes[j].App.AppKey })
+	for _, change := range changes {
+		c.notifyAppChange(handlers, change)
+	}
+}
+
+// appChangeHandlers 返回订阅者的快照，调用方需持有 appsMu
+func (c *Client) appChangeHandlers() []AppChangeHandler {
+	handlers := make([]AppChangeHandler, 0, len(c.appHandlers))
+	for _, h := range c.appHandlers {
+		handlers = append(handlers, h.handler)
+	}
+	return handlers
+}
+
+// notifyAppChange 记录日志并通知订阅者
+func (c *Client) notifyAppChange(handlers []AppChangeHandler, change AppChange) {
+	switch change.Type {
+	case AppJoined:
+		c.logger.Debugf("App %s joined (version %s, status %s)", change.App.AppKey, change.App.Version, change.App.Status)
+	case AppUpdated:
+		c.logger.Debugf("App %s updated (version %s, status %s)", change.App.AppKey, change.App.Version, change.App.Status)
+	case AppLeft:
+		c.logger.Debugf("App %s left", change.App.AppKey)
+	}
+
+	for _, handler := range handlers {
+		handler(change)
+	}
+}
+
+// Apps 返回当前在线的 App（包括自身），按 AppKey 排序
+// 列表根据观察到的心跳维护，启动后需要一个心跳间隔才能发现所有 App
+func (c *Client) Apps() []AppInfo {
+	c.appsMu.Lock()
+	defer c.appsMu.Unlock()
+
+	apps := make([]AppInfo, 0, len(c.apps))
+	for _, info := range c.apps {
+		apps = append(apps, *info)
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].AppKey < apps[j].AppKey })
+	return apps
+}
+
+// App 返回指定 App 的信息，App 不在线时返回 false
+func (c *Client) App(appKey string) (AppInfo, bool) {
+	c.appsMu.Lock()
+	defer c.appsMu.Unlock()
+
+	info, ok := c.apps[appKey]
+	if !ok {
+		return AppInfo{}, false
+	}
+	return *info, true
+}
+
+// OnAppChange 订阅 App 上线、变化和离开，返回取消订阅的函数
+// 回调在收到心跳的 goroutine 或心跳定时器中同步执行，不应长时间阻塞
+func (c *Client) OnAppChange(handler AppChangeHandler) (cancel func()) {
+	c.appsMu.Lock()
+	defer c.appsMu.Unlock()
+
+	c.nextAppHandler++
+	id := c.nextAppHandler
+	c.appHandlers = append(c.appHandlers, appChangeSubscriber{id: id, handler: handler})
+
+	return func() {
+		c.appsMu.Lock()
+		defer c.appsMu.Unlock()
+		for i, h := range c.appHandlers {
+			if h.id == id {
+				c.appHandlers = append(c.appHandlers[:i:i], c.appHandlers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// sendStoppedHeartbeat 关闭前发送 stopped 心跳，edge-agent 和其他 App 可以立即得知 App 已离开
+func (c *Client) sendStoppedHeartbeat() {
+	if !c.transport.IsConnected() {
+		return
+	}
+
+	heartbeat := HeartbeatData{
+		AppKey:    c.opts.AppKey,
+		Version:   c.opts.AppVersion,
+		Status:    heartbeatStatusStopped,
+		Metrics:   map[string]interface{}{"uptime": c.GetUptime()},
+		Timestamp: c.now().Unix(),
+		Streams:   c.Streams(),
+	}
+	if err := c.publish(context.Background(), c.topics.Heartbeat(), heartbeat); err != nil {
+		c.logger.Errorf("Failed to publish stopped heartbeat: %v", err)
+	}
+}
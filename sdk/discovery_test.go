@@ -0,0 +1,21 @@
+package sdk
+
+import "testing"
+
+func TestIsStreamSubject(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    bool
+	}{
+		{"app.camera.data.heartbeat", true},
+		{"app.camera.heartbeat", false},
+		{"app.app.camera.heartbeat", false},
+		{"app.app.camera.data.heartbeat", false},
+		{"app.camera.data.frames", true},
+	}
+	for _, tt := range tests {
+		if got := isStreamSubject(tt.subject); got != tt.want {
+			t.Errorf("isStreamSubject(%q) = %v, want %v", tt.subject, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package sdk
+
+// lockFile 非 Unix 平台不支持 flock，退化为无锁
+func lockFile(path string, exclusive bool) (func(), error) {
+	return func() {}, nil
+}
+
+// syncDir 非 Unix 平台无法对目录执行 fsync，直接忽略
+func syncDir(dir string) error {
+	return nil
+}
@@ -1,6 +1,7 @@
 package sdk
 
 import (
+	"context"
 	"time"
 )
 
@@ -10,9 +11,8 @@ func (c *Client) initHeartbeat() error {
 	return nil
 }
 
-// startHeartbeat 启动心跳发送
-func (c *Client) startHeartbeat(interval time.Duration) {
-	ticker := time.NewTicker(interval)
+// startHeartbeat 启动心跳发送，ticker 由调用方同步创建
+func (c *Client) startHeartbeat(ticker Ticker) {
 	defer ticker.Stop()
 
 	// 立即发送一次心跳
@@ -20,9 +20,10 @@ func (c *Client) startHeartbeat(interval time.Duration) {
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
 			if c.isRunning() {
 				c.sendHeartbeat()
+				c.expireApps()
 			}
 		case <-c.heartbeatStop:
 			return
@@ -32,7 +33,7 @@ func (c *Client) startHeartbeat(interval time.Duration) {
 
 // sendHeartbeat 发送心跳
 func (c *Client) sendHeartbeat() {
-	if !c.isRunning() || !c.nats.IsConnected() {
+	if !c.isRunning() || !c.transport.IsConnected() {
 		return
 	}
 
@@ -41,7 +42,8 @@ func (c *Client) sendHeartbeat() {
 		AppKey:    c.opts.AppKey,
 		Version:   c.opts.AppVersion,
 		Status:    "running",
-		Timestamp: time.Now().Unix(),
+		Timestamp: c.now().Unix(),
+		Streams:   c.Streams(),
 	}
 
 	// 添加默认指标
@@ -64,13 +66,13 @@ func (c *Client) sendHeartbeat() {
 	heartbeat.Metrics = metrics
 
 	// 发布心跳
-	if err := c.nats.Publish(c.topics.Heartbeat(), heartbeat); err != nil {
+	if err := c.publish(context.Background(), c.topics.Heartbeat(), heartbeat); err != nil {
 		c.logger.Errorf("Failed to publish heartbeat: %v", err)
 	}
 }
 
 // StartHeartbeat 手动启动心跳（兼容旧 API）
 func (c *Client) StartHeartbeat(interval time.Duration) {
-	go c.startHeartbeat(interval)
+	go c.startHeartbeat(c.clock.NewTicker(interval))
 }
 
@@ -0,0 +1,44 @@
+// schemagen 根据 SDK 数据模型生成消息的 JSON Schema 和 AsyncAPI 文档
+//
+//	go run ./internal/schemagen -out ../schema
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/punk-one/edge-app-sdk/sdk"
+)
+
+func main() {
+	out := flag.String("out", "schema", "输出目录")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		log.Fatalf("failed to create output directory: %v", err)
+	}
+
+	for name, schema := range sdk.Schemas() {
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			log.Fatalf("failed to marshal schema %s: %v", name, err)
+		}
+		write(filepath.Join(*out, name+".schema.json"), data)
+	}
+
+	data, err := sdk.AsyncAPI()
+	if err != nil {
+		log.Fatalf("failed to generate AsyncAPI document: %v", err)
+	}
+	write(filepath.Join(*out, "asyncapi.json"), data)
+}
+
+// write 写入文件，末尾追加换行
+func write(path string, data []byte) {
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		log.Fatalf("failed to write %s: %v", path, err)
+	}
+}
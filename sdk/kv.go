@@ -0,0 +1,126 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrKeyNotFound 键不存在或已删除
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrKeyExists Create 时键已存在
+	ErrKeyExists = errors.New("key exists")
+	// ErrRevisionMismatch Update 时键的最新版本与期望的版本不一致（已被其他写入者修改）
+	ErrRevisionMismatch = errors.New("revision mismatch")
+)
+
+// KVOperation KV 条目的操作类型
+type KVOperation string
+
+const (
+	KVPut    KVOperation = "put"    // 写入
+	KVDelete KVOperation = "delete" // 删除
+)
+
+// KVEntry KV 条目
+type KVEntry struct {
+	Bucket    string      // bucket 名称
+	Key       string      // 键
+	Value     []byte      // 值，删除时为空
+	Revision  uint64      // 版本号，bucket 内单调递增，用于 Update 的乐观并发控制
+	Created   time.Time   // 写入时间
+	Operation KVOperation // 操作类型
+}
+
+// KVWatchHandler KV 变化回调
+type KVWatchHandler func(entry KVEntry)
+
+// KeyValue App 的 KV 存储，所有操作限定在一个 bucket 内
+// 键为点分隔的 token，只能包含字母、数字和 - / _ =，不能以点开头或结尾
+type KeyValue interface {
+	// Bucket bucket 名称
+	Bucket() string
+	// Backend 存储后端
+	Backend() StorageBackend
+	// Get 读取键的最新值，键不存在或已删除时返回 ErrKeyNotFound
+	Get(ctx context.Context, key string) (KVEntry, error)
+	// Put 写入键，返回新的版本号
+	Put(ctx context.Context, key string, value []byte) (uint64, error)
+	// Create 仅在键不存在（或已删除）时写入，否则返回 ErrKeyExists
+	Create(ctx context.Context, key string, value []byte) (uint64, error)
+	// Update 仅在键的最新版本为 revision 时写入，否则返回 ErrRevisionMismatch
+	Update(ctx context.Context, key string, value []byte, revision uint64) (uint64, error)
+	// Delete 删除键
+	Delete(ctx context.Context, key string) error
+	// Keys 返回所有未删除的键，按名称排序
+	Keys(ctx context.Context) ([]string, error)
+	// Watch 监听匹配 keys 的键（可以使用 * 和 > 通配符），先回调当前值，再回调后续变化；返回停止监听的函数
+	Watch(keys string, handler KVWatchHandler) (stop func(), err error)
+}
+
+// kvStore KeyValue 的实现，Client 关闭时停止监听
+type kvStore interface {
+	KeyValue
+	close()
+}
+
+// validKVKey KV 键允许的字符
+var validKVKey = regexp.MustCompile(`^[-/_=.a-zA-Z0-9]+$`)
+
+// validateKVKey 检查键，watch 为 true 时允许通配符
+func validateKVKey(key string, watch bool) error {
+	if key == "" || strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") || strings.Contains(key, "..") {
+		return fmt.Errorf("invalid key: %q", key)
+	}
+	for _, token := range strings.Split(key, ".") {
+		if watch && (token == "*" || token == ">") {
+			continue
+		}
+		if !validKVKey.MatchString(token) {
+			return fmt.Errorf("invalid key: %q", key)
+		}
+	}
+	return nil
+}
+
+// KV 返回 App 的 KV 存储（bucket 为 Options.KVBucket），第一次调用时打开
+// 传输层支持 JetStream 且已连接时使用 JetStream KV（bucket 不存在时创建），否则使用本地文件 <StorageDir>/kv/<bucket>.json；
+// 后端在打开时确定，之后不会切换
+func (c *Client) KV(ctx context.Context) (KeyValue, error) {
+	c.kvMu.Lock()
+	defer c.kvMu.Unlock()
+	if c.kv != nil {
+		return c.kv, nil
+	}
+
+	bucket := c.opts.KVBucket
+	js, err := c.jetStream(ctx)
+	if err == nil {
+		c.kv, err = c.openJetStreamKV(ctx, js, bucket)
+		if err != nil {
+			return nil, err
+		}
+		c.logger.Debugf("Opened JetStream KV bucket %s", bucket)
+		return c.kv, nil
+	}
+
+	c.logger.Infof("Using local KV store for bucket %s: %v", bucket, err)
+	c.kv, err = c.openLocalKV(bucket)
+	if err != nil {
+		return nil, err
+	}
+	return c.kv, nil
+}
+
+// closeKV 停止 KV 的所有监听
+func (c *Client) closeKV() {
+	c.kvMu.Lock()
+	defer c.kvMu.Unlock()
+	if c.kv != nil {
+		c.kv.close()
+	}
+}
@@ -0,0 +1,186 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/sirupsen/logrus"
+)
+
+// jetStreamKV 基于 JetStream KV 的 KeyValue
+type jetStreamKV struct {
+	kv     jetstream.KeyValue
+	logger *logrus.Logger
+
+	mu       sync.Mutex
+	watchers map[int]jetstream.KeyWatcher
+	nextID   int
+}
+
+// openJetStreamKV 打开 bucket，不存在时创建
+func (c *Client) openJetStreamKV(ctx context.Context, js jetstream.JetStream, bucket string) (*jetStreamKV, error) {
+	kv, err := js.KeyValue(ctx, bucket)
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+			Bucket:      bucket,
+			Description: "edge app " + c.opts.AppKey,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open KV bucket %s: %w", bucket, err)
+	}
+	return &jetStreamKV{kv: kv, logger: c.logger, watchers: map[int]jetstream.KeyWatcher{}}, nil
+}
+
+func (s *jetStreamKV) Bucket() string {
+	return s.kv.Bucket()
+}
+
+func (s *jetStreamKV) Backend() StorageBackend {
+	return StorageJetStream
+}
+
+func (s *jetStreamKV) Get(ctx context.Context, key string) (KVEntry, error) {
+	if err := validateKVKey(key, false); err != nil {
+		return KVEntry{}, err
+	}
+	entry, err := s.kv.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) || errors.Is(err, jetstream.ErrKeyDeleted) {
+			return KVEntry{}, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+		}
+		return KVEntry{}, fmt.Errorf("failed to get key %s: %w", key, err)
+	}
+	return fromJetStreamEntry(entry), nil
+}
+
+func (s *jetStreamKV) Put(ctx context.Context, key string, value []byte) (uint64, error) {
+	if err := validateKVKey(key, false); err != nil {
+		return 0, err
+	}
+	revision, err := s.kv.Put(ctx, key, value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to put key %s: %w", key, err)
+	}
+	return revision, nil
+}
+
+func (s *jetStreamKV) Create(ctx context.Context, key string, value []byte) (uint64, error) {
+	if err := validateKVKey(key, false); err != nil {
+		return 0, err
+	}
+	revision, err := s.kv.Create(ctx, key, value)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			return 0, fmt.Errorf("%w: %s", ErrKeyExists, key)
+		}
+		return 0, fmt.Errorf("failed to create key %s: %w", key, err)
+	}
+	return revision, nil
+}
+
+func (s *jetStreamKV) Update(ctx context.Context, key string, value []byte, revision uint64) (uint64, error) {
+	if err := validateKVKey(key, false); err != nil {
+		return 0, err
+	}
+	newRevision, err := s.kv.Update(ctx, key, value, revision)
+	if err != nil {
+		// 期望版本不一致时服务端返回 wrong last sequence，与 ErrKeyExists 的错误码相同
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			return 0, fmt.Errorf("%w: %s", ErrRevisionMismatch, key)
+		}
+		return 0, fmt.Errorf("failed to update key %s: %w", key, err)
+	}
+	return newRevision, nil
+}
+
+func (s *jetStreamKV) Delete(ctx context.Context, key string) error {
+	if err := validateKVKey(key, false); err != nil {
+		return err
+	}
+	if err := s.kv.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete key %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *jetStreamKV) Keys(ctx context.Context) ([]string, error) {
+	keys, err := s.kv.Keys(ctx)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrNoKeysFound) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *jetStreamKV) Watch(keys string, handler KVWatchHandler) (func(), error) {
+	if err := validateKVKey(keys, true); err != nil {
+		return nil, err
+	}
+	watcher, err := s.kv.Watch(context.Background(), keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch %s: %w", keys, err)
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	s.watchers[id] = watcher
+	s.mu.Unlock()
+
+	go func() {
+		for entry := range watcher.Updates() {
+			// 当前值回调完毕后收到 nil
+			if entry != nil {
+				handler(fromJetStreamEntry(entry))
+			}
+		}
+	}()
+
+	return func() {
+		s.mu.Lock()
+		_, ok := s.watchers[id]
+		delete(s.watchers, id)
+		s.mu.Unlock()
+		if !ok {
+			return
+		}
+		if err := watcher.Stop(); err != nil {
+			s.logger.Warnf("Failed to stop KV watcher: %v", err)
+		}
+	}, nil
+}
+
+func (s *jetStreamKV) close() {
+	s.mu.Lock()
+	watchers := s.watchers
+	s.watchers = map[int]jetstream.KeyWatcher{}
+	s.mu.Unlock()
+
+	for _, watcher := range watchers {
+		watcher.Stop()
+	}
+}
+
+// fromJetStreamEntry 转换 JetStream KV 条目，Purge 视为删除
+func fromJetStreamEntry(entry jetstream.KeyValueEntry) KVEntry {
+	op := KVPut
+	if entry.Operation() != jetstream.KeyValuePut {
+		op = KVDelete
+	}
+	return KVEntry{
+		Bucket:    entry.Bucket(),
+		Key:       entry.Key(),
+		Value:     entry.Value(),
+		Revision:  entry.Revision(),
+		Created:   entry.Created(),
+		Operation: op,
+	}
+}
@@ -0,0 +1,424 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// localKVFileMode 本地 KV 文件权限，值可能包含敏感数据
+const localKVFileMode = 0600
+
+// localKV 基于本地文件的 KeyValue，JetStream 不可用时使用
+// 每次写入后原子地重写整个文件，适合 App 的少量状态；删除的键保留删除标记，与 JetStream 的版本语义一致
+// 共享 StorageDir 的多个进程可以同时使用同一个 bucket：写入时持有 <文件>.lock 写锁并重新读取文件，
+// 读取时文件被其他进程修改过则重新加载；有监听者时每 localKVPollInterval 检查一次文件，将其他进程的修改通知监听者
+type localKV struct {
+	bucket string
+	path   string
+	now    func() time.Time
+	logger *logrus.Logger
+
+	mu       sync.Mutex
+	file     os.FileInfo // 最近一次加载的文件信息，用于判断文件是否被其他进程修改
+	revision uint64
+	entries  map[string]*localKVEntry
+	watchers map[int]*localKVWatcher
+	nextID   int
+	pollStop chan struct{} // 有监听者时轮询文件，最后一个监听者取消后关闭
+}
+
+// localKVPollInterval 有监听者时检查 KV 文件是否被其他进程修改的间隔
+const localKVPollInterval = time.Second
+
+// localKVEntry 本地 KV 文件中的条目
+type localKVEntry struct {
+	Value    []byte    `json:"value,omitempty"`
+	Revision uint64    `json:"revision"`
+	Created  time.Time `json:"created"`
+	Deleted  bool      `json:"deleted,omitempty"`
+}
+
+// localKVFile 本地 KV 文件格式
+type localKVFile struct {
+	Revision uint64                   `json:"revision"`
+	Entries  map[string]*localKVEntry `json:"entries"`
+}
+
+// openLocalKV 打开（或创建）本地 KV 文件
+func (c *Client) openLocalKV(bucket string) (*localKV, error) {
+	dir := filepath.Join(c.opts.StorageDir, "kv")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create KV directory: %w", err)
+	}
+
+	s := &localKV{
+		bucket:   bucket,
+		path:     filepath.Join(dir, bucket+".json"),
+		now:      c.now,
+		logger:   c.logger,
+		entries:  map[string]*localKVEntry{},
+		watchers: map[int]*localKVWatcher{},
+	}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload 文件被（其他进程）修改过时重新加载，并将新的变化通知监听者；调用方需持有 mu
+// 写入通过 rename 原子替换文件，读取不需要加锁
+func (s *localKV) reload() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read KV file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read KV file: %w", err)
+	}
+	if s.file != nil && os.SameFile(s.file, info) && s.file.ModTime().Equal(info.ModTime()) && s.file.Size() == info.Size() {
+		return nil
+	}
+
+	var file localKVFile
+	if err := json.NewDecoder(f).Decode(&file); err != nil {
+		return fmt.Errorf("failed to parse KV file %s: %w", s.path, err)
+	}
+	if file.Entries == nil {
+		file.Entries = map[string]*localKVEntry{}
+	}
+
+	var changes []KVEntry
+	for key, entry := range file.Entries {
+		if entry.Revision > s.revision {
+			changes = append(changes, s.toEntry(key, entry))
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Revision < changes[j].Revision })
+
+	s.file = info
+	s.revision = file.Revision
+	s.entries = file.Entries
+	s.notify(changes...)
+	return nil
+}
+
+// notify 将变化通知匹配的监听者，调用方需持有 mu
+func (s *localKV) notify(changes ...KVEntry) {
+	for _, w := range s.watchers {
+		var matched []KVEntry
+		for _, change := range changes {
+			if matchKey(w.keys, change.Key) {
+				matched = append(matched, change)
+			}
+		}
+		w.push(matched...)
+	}
+}
+
+func (s *localKV) Bucket() string {
+	return s.bucket
+}
+
+func (s *localKV) Backend() StorageBackend {
+	return StorageLocal
+}
+
+func (s *localKV) Get(ctx context.Context, key string) (KVEntry, error) {
+	if err := validateKVKey(key, false); err != nil {
+		return KVEntry{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return KVEntry{}, err
+	}
+
+	entry, ok := s.entries[key]
+	if !ok || entry.Deleted {
+		return KVEntry{}, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	return s.toEntry(key, entry), nil
+}
+
+func (s *localKV) Put(ctx context.Context, key string, value []byte) (uint64, error) {
+	return s.write(key, value, false, nil)
+}
+
+func (s *localKV) Create(ctx context.Context, key string, value []byte) (uint64, error) {
+	return s.write(key, value, false, func(existing *localKVEntry) error {
+		if existing != nil && !existing.Deleted {
+			return fmt.Errorf("%w: %s", ErrKeyExists, key)
+		}
+		return nil
+	})
+}
+
+func (s *localKV) Update(ctx context.Context, key string, value []byte, revision uint64) (uint64, error) {
+	return s.write(key, value, false, func(existing *localKVEntry) error {
+		var current uint64
+		if existing != nil {
+			current = existing.Revision
+		}
+		if current != revision {
+			return fmt.Errorf("%w: %s", ErrRevisionMismatch, key)
+		}
+		return nil
+	})
+}
+
+func (s *localKV) Delete(ctx context.Context, key string) error {
+	_, err := s.write(key, nil, true, nil)
+	return err
+}
+
+// write 检查后写入条目（或删除标记），保存文件并通知监听者；保存失败时恢复内存中的状态
+// 持有文件写锁完成“重新读取-检查-写入”，与其他进程的写入串行
+func (s *localKV) write(key string, value []byte, deleted bool, check func(existing *localKVEntry) error) (uint64, error) {
+	if err := validateKVKey(key, false); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path+".lock", true)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	if err := s.reload(); err != nil {
+		return 0, err
+	}
+
+	existing := s.entries[key]
+	if check != nil {
+		if err := check(existing); err != nil {
+			return 0, err
+		}
+	}
+
+	s.revision++
+	entry := &localKVEntry{
+		Value:    append([]byte(nil), value...),
+		Revision: s.revision,
+		Created:  s.now().UTC(),
+		Deleted:  deleted,
+	}
+	s.entries[key] = entry
+	if err := s.save(); err != nil {
+		s.revision--
+		if existing != nil {
+			s.entries[key] = existing
+		} else {
+			delete(s.entries, key)
+		}
+		return 0, err
+	}
+
+	s.notify(s.toEntry(key, entry))
+	return entry.Revision, nil
+}
+
+// save 原子地写入 KV 文件，调用方需持有 mu 和文件写锁
+func (s *localKV) save() error {
+	data, err := json.Marshal(localKVFile{Revision: s.revision, Entries: s.entries})
+	if err != nil {
+		return fmt.Errorf("failed to marshal KV file: %w", err)
+	}
+	if err := writeFileAtomic(s.path, data, localKVFileMode); err != nil {
+		return fmt.Errorf("failed to write KV file: %w", err)
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.file = info
+	}
+	return nil
+}
+
+func (s *localKV) Keys(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(s.entries))
+	for key, entry := range s.entries {
+		if !entry.Deleted {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *localKV) Watch(keys string, handler KVWatchHandler) (func(), error) {
+	if err := validateKVKey(keys, true); err != nil {
+		return nil, err
+	}
+	w := &localKVWatcher{
+		keys:    keys,
+		handler: handler,
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	if err := s.reload(); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	// 当前值按版本顺序回调，持有锁保证不会与之后的变化交错
+	var current []KVEntry
+	for key, entry := range s.entries {
+		if !entry.Deleted && matchKey(keys, key) {
+			current = append(current, s.toEntry(key, entry))
+		}
+	}
+	sort.Slice(current, func(i, j int) bool { return current[i].Revision < current[j].Revision })
+	w.push(current...)
+	s.nextID++
+	id := s.nextID
+	s.watchers[id] = w
+	if s.pollStop == nil {
+		s.pollStop = make(chan struct{})
+		go s.poll(s.pollStop)
+	}
+	s.mu.Unlock()
+
+	go w.run()
+
+	return func() {
+		s.mu.Lock()
+		delete(s.watchers, id)
+		s.stopPollLocked()
+		s.mu.Unlock()
+		w.stop()
+	}, nil
+}
+
+// poll 定时重新加载 KV 文件，将其他进程的修改通知监听者，直到 stop 关闭
+func (s *localKV) poll(stop chan struct{}) {
+	ticker := time.NewTicker(localKVPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			if err := s.reload(); err != nil {
+				s.logger.Debugf("Failed to reload KV file %s: %v", s.path, err)
+			}
+			s.mu.Unlock()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// stopPollLocked 没有监听者时停止轮询，调用方需持有 mu
+func (s *localKV) stopPollLocked() {
+	if len(s.watchers) == 0 && s.pollStop != nil {
+		close(s.pollStop)
+		s.pollStop = nil
+	}
+}
+
+func (s *localKV) close() {
+	s.mu.Lock()
+	watchers := s.watchers
+	s.watchers = map[int]*localKVWatcher{}
+	s.stopPollLocked()
+	s.mu.Unlock()
+
+	for _, w := range watchers {
+		w.stop()
+	}
+}
+
+// toEntry 转换为 KVEntry
+func (s *localKV) toEntry(key string, entry *localKVEntry) KVEntry {
+	e := KVEntry{
+		Bucket:    s.bucket,
+		Key:       key,
+		Revision:  entry.Revision,
+		Created:   entry.Created,
+		Operation: KVPut,
+	}
+	if entry.Deleted {
+		e.Operation = KVDelete
+	} else {
+		e.Value = append([]byte(nil), entry.Value...)
+	}
+	return e
+}
+
+// localKVWatcher 本地 KV 监听者，变化放入队列后在独立的 goroutine 中按顺序回调，回调中可以读写 KV
+type localKVWatcher struct {
+	keys    string
+	handler KVWatchHandler
+
+	mu     sync.Mutex
+	queue  []KVEntry
+	notify chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// push 将变化放入队列
+func (w *localKVWatcher) push(entries ...KVEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	w.mu.Lock()
+	w.queue = append(w.queue, entries...)
+	w.mu.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run 按顺序回调队列中的变化，停止后退出
+func (w *localKVWatcher) run() {
+	for {
+		select {
+		case <-w.notify:
+		case <-w.done:
+			return
+		}
+
+		w.mu.Lock()
+		entries := w.queue
+		w.queue = nil
+		w.mu.Unlock()
+
+		for _, entry := range entries {
+			select {
+			case <-w.done:
+				return
+			default:
+			}
+			w.handler(entry)
+		}
+	}
+}
+
+// stop 停止回调
+func (w *localKVWatcher) stop() {
+	w.once.Do(func() { close(w.done) })
+}
@@ -0,0 +1,90 @@
+package sdk
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// openTestLocalKVs 打开同一个 bucket 文件的多个 localKV，模拟共享 StorageDir 的多个进程
+func openTestLocalKVs(t *testing.T, n int) []*localKV {
+	t.Helper()
+	c := &Client{opts: Options{StorageDir: t.TempDir()}, clock: realClock{}, logger: logrus.StandardLogger()}
+	kvs := make([]*localKV, n)
+	for i := range kvs {
+		kv, err := c.openLocalKV("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(kv.close)
+		kvs[i] = kv
+	}
+	return kvs
+}
+
+// 多个实例并发 Update 同一个键，版本检查基于最新的文件内容，不会丢失更新
+func TestLocalKVConcurrentUpdate(t *testing.T) {
+	ctx := context.Background()
+	kvs := openTestLocalKVs(t, 3)
+	if _, err := kvs[0].Put(ctx, "n", []byte("0")); err != nil {
+		t.Fatal(err)
+	}
+
+	const perInstance = 30
+	var wg sync.WaitGroup
+	for _, kv := range kvs {
+		wg.Add(1)
+		go func(kv *localKV) {
+			defer wg.Done()
+			for done := 0; done < perInstance; {
+				entry, err := kv.Get(ctx, "n")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				n, _ := strconv.Atoi(string(entry.Value))
+				if _, err := kv.Update(ctx, "n", []byte(strconv.Itoa(n+1)), entry.Revision); err == nil {
+					done++
+				}
+			}
+		}(kv)
+	}
+	wg.Wait()
+
+	entry, err := kvs[0].Get(ctx, "n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strconv.Itoa(perInstance * len(kvs)); string(entry.Value) != want {
+		t.Fatalf("n = %s, want %s", entry.Value, want)
+	}
+}
+
+// 只监听不读写的实例也能收到其他实例的修改
+func TestLocalKVWatchOtherInstance(t *testing.T) {
+	ctx := context.Background()
+	kvs := openTestLocalKVs(t, 2)
+
+	changes := make(chan KVEntry, 10)
+	stop, err := kvs[1].Watch(">", func(entry KVEntry) { changes <- entry })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	if _, err := kvs[0].Put(ctx, "offset", []byte("1024")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case entry := <-changes:
+		if entry.Key != "offset" || string(entry.Value) != "1024" || entry.Operation != KVPut {
+			t.Fatalf("unexpected change: %+v", entry)
+		}
+	case <-time.After(3 * localKVPollInterval):
+		t.Fatal("watcher did not see the other instance's write")
+	}
+}
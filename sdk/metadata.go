@@ -0,0 +1,143 @@
+package sdk
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 标准消息头，SDK 发送的每条消息都会携带
+const (
+	HeaderAppKey        = "Edge-App-Key"        // 发送方 App 标识
+	HeaderAppVersion    = "Edge-App-Version"    // 发送方 App 版本
+	HeaderMessageType   = "Edge-Msg-Type"       // 消息类型，见 MessageType* 常量
+	HeaderSchemaVersion = "Edge-Schema-Version" // 协议版本（消息体结构版本），见 ProtocolV*
+	HeaderMessageID     = "Edge-Msg-Id"         // 消息 ID
+	HeaderTraceParent   = "traceparent"         // W3C Trace Context
+	HeaderTraceState    = "tracestate"          // W3C Trace Context 厂商扩展
+)
+
+// 消息类型
+const (
+	MessageTypeHeartbeat     = "heartbeat"
+	MessageTypeLog           = "log"
+	MessageTypeEvent         = "event"
+	MessageTypeStatus        = "status"
+	MessageTypeCommand       = "cmd"
+	MessageTypeCommandResult = "cmd.result"
+	MessageTypeConfig        = "config" // 配置下发、配置拉取的回复、本地配置上报
+	MessageTypeConfigAck     = "config.ack"
+	MessageTypeConfigRequest = "config.request"
+	MessageTypeProtocolHello = "protocol.hello"
+
+	// edge-agent 发送的协议协商回复
+	MessageTypeProtocolHelloReply = "protocol.hello.reply"
+	// App 之间的数据流，消息体为 App 自定义的数据
+	MessageTypeData = "data"
+)
+
+// Metadata 从消息头中解析的元数据
+type Metadata struct {
+	AppKey        string // 发送方 App 标识（edge-agent 发送的消息可能为空）
+	AppVersion    string // 发送方 App 版本
+	MessageType   string // 消息类型
+	SchemaVersion string // 协议版本，为空表示发送方未携带（v1）
+	MessageID     string // 消息 ID
+	TraceParent   string // W3C traceparent
+	TraceState    string // W3C tracestate
+	Header        Header // 原始消息头，可读取自定义消息头
+
+	ctx context.Context // 处理该消息的 span 所在的 context
+}
+
+// MetadataFromHeader 从消息头解析元数据
+func MetadataFromHeader(h Header) Metadata {
+	return Metadata{
+		AppKey:        h.Get(HeaderAppKey),
+		AppVersion:    h.Get(HeaderAppVersion),
+		MessageType:   h.Get(HeaderMessageType),
+		SchemaVersion: h.Get(HeaderSchemaVersion),
+		MessageID:     h.Get(HeaderMessageID),
+		TraceParent:   h.Get(HeaderTraceParent),
+		TraceState:    h.Get(HeaderTraceState),
+		Header:        h,
+	}
+}
+
+// Context 返回处理该消息的 span 所在的 context，用于创建子 span 或通过 EmitEventContext/LogContext 关联追踪
+func (m Metadata) Context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
+// TraceID 返回 traceparent 中的 trace ID，traceparent 无效时返回空字符串
+func (m Metadata) TraceID() string {
+	ctx := propagation.TraceContext{}.Extract(context.Background(), m.Header)
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// messageTypeOf 根据消息体类型确定消息类型
+func messageTypeOf(data interface{}) string {
+	switch data.(type) {
+	case HeartbeatData, *HeartbeatData:
+		return MessageTypeHeartbeat
+	case LogData, *LogData:
+		return MessageTypeLog
+	case EventData, *EventData:
+		return MessageTypeEvent
+	case StatusData, *StatusData:
+		return MessageTypeStatus
+	case Command, *Command:
+		return MessageTypeCommand
+	case CommandResult, *CommandResult:
+		return MessageTypeCommandResult
+	case ConfigData, *ConfigData:
+		return MessageTypeConfig
+	case ConfigAck, *ConfigAck:
+		return MessageTypeConfigAck
+	case ConfigRequest, *ConfigRequest:
+		return MessageTypeConfigRequest
+	case ProtocolHello, *ProtocolHello:
+		return MessageTypeProtocolHello
+	case ProtocolHelloReply, *ProtocolHelloReply:
+		return MessageTypeProtocolHelloReply
+	default:
+		return ""
+	}
+}
+
+// setStandardHeaders 设置标准消息头，并将 ctx 中的追踪上下文写入消息头
+// ctx 中没有追踪上下文时开始新的（未采样的）追踪，保证每条消息都可以被关联
+func (c *Client) setStandardHeaders(ctx context.Context, h Header, msgType string, version int) {
+	h.Set(HeaderAppKey, c.opts.AppKey)
+	if c.opts.AppVersion != "" {
+		h.Set(HeaderAppVersion, c.opts.AppVersion)
+	}
+	if msgType != "" {
+		h.Set(HeaderMessageType, msgType)
+	}
+	h.Set(HeaderSchemaVersion, strconv.Itoa(version))
+	h.Set(HeaderMessageID, randomHex(16))
+
+	c.propagator.Inject(ctx, h)
+	if h.Get(HeaderTraceParent) == "" {
+		h.Set(HeaderTraceParent, "00-"+randomHex(16)+"-"+randomHex(8)+"-00")
+	}
+}
+
+// randomHex 生成 n 字节的随机十六进制字符串
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b) // 自 Go 1.24 起 crypto/rand.Read 不会返回错误
+	return hex.EncodeToString(b)
+}
@@ -1,21 +1,74 @@
 package sdk
 
-import "time"
+import (
+	"os"
+	"time"
+)
 
 // Options SDK 初始化选项
 type Options struct {
-	AppKey           string        // App 标识，如 "app.camera"
-	AppVersion       string        // 版本号
-	NatsURL          string        // NATS 服务地址，如 "nats://127.0.0.1:4222"
+	AppKey            string        // App 标识，如 "app.camera"
+	AppVersion        string        // 版本号
+	NatsURL           string        // NATS 服务地址，如 "nats://127.0.0.1:4222"
+	NATS              NATSOptions   // NATS 连接选项（TLS、认证、集群、重连），仅在使用默认 NATS 传输层时生效
 	HeartbeatInterval time.Duration // 心跳间隔，默认 30 秒
-	LogLevel         string        // 日志级别（Trace/Debug/Info/Warn/Error/Fatal/Panic），默认 Info
+	LogLevel          string        // 日志级别（Trace/Debug/Info/Warn/Error/Fatal/Panic），默认 Info
+	Transport         Transport     // 自定义传输层（如 MQTT、内存），为空时使用 NatsURL 连接 NATS；Client 关闭时一并关闭
+	Clock             Clock         // 时钟（心跳定时和时间戳），默认为系统时钟，测试时可替换
+	Codec             Codec         // 发送消息使用的编码（JSON/MessagePack/CBOR/Protobuf），默认 JSON
+
+	// 协议版本：启动和重连后与 edge-agent 协商
+	ProtocolVersions           []int         // 支持的协议版本，按优先级排序，默认 [2, 1]；只有一个版本时不协商
+	ProtocolNegotiationTimeout time.Duration // 协商超时时间，默认 2 秒；超时或 edge-agent 不支持协商时使用 v1
+
+	// 服务发现：根据观察到的 app.*.heartbeat 维护节点上的 App 列表（Apps、OnAppChange）
+	DisableDiscovery bool          // 是否关闭服务发现
+	DiscoveryTTL     time.Duration // 超过该时间未收到心跳的 App 视为已离开，默认 3 倍 HeartbeatInterval
+
+	// App 间调用（Call）的默认选项，可通过 CallOptions 按调用覆盖
+	CallTimeout       time.Duration // 每次尝试等待回复的超时时间，默认 5 秒
+	CallRetries       int           // 无响应后的重试次数，默认 0（不重试）；超时重试需要 CallOptions.RetryOnTimeout
+	CallRetryInterval time.Duration // 第一次重试前的等待时间，之后每次翻倍，默认 100 毫秒
+
+	// 存储（KV、对象存储）：优先使用 NATS JetStream，传输层不支持、未连接或服务端未开启 JetStream 时使用本地文件
+	KVBucket         string // KV bucket 名称，默认由 AppKey 生成（如 app.camera → app_camera）
+	StorageDir       string // 本地存储目录，默认 <ConfigDir>/data
+	DisableJetStream bool   // 不使用 JetStream，始终使用本地存储
+
+	// 对象存储
+	ObjectBucket    string        // 对象存储 bucket 名称，默认与 KVBucket 的默认值相同
+	ObjectChunkSize int           // 对象分块大小（字节），默认 DefaultObjectChunkSize（128 KB）
+	ObjectTTL       time.Duration // 对象默认的过期时间，默认 0（不过期），可通过 ObjectPutOptions.TTL 按对象覆盖
+
+	// 可观测性
+	Tracing TracingOptions // OpenTelemetry 追踪（导出方式、采样、TracerProvider）
+
+	// 调试
+	ValidateMessages bool // 按 JSON Schema 校验收发的消息，不符合时记录警告；会增加编解码开销，建议只在开发调试时开启
+
+	// 配置文件（均可通过 EDGE_APP_CONFIG_* 环境变量覆盖）
+	ConfigDir      string       // 配置文件目录，默认 /usr/local/edge/apps/<AppKey>
+	ConfigFile     string       // 配置文件名，默认 config.<格式扩展名>
+	ConfigFormat   ConfigFormat // 配置文件格式（yaml/json/toml），默认根据文件扩展名推断，否则为 yaml
+	ConfigFileMode os.FileMode  // 配置文件权限，默认 0644
+	SecretKeyFile  string       // 配置密钥加密使用的设备密钥文件，默认 <ConfigDir>/.secret.key，不存在时自动生成
+	WatchConfig    bool         // 是否监听配置文件的本地修改，修改后自动应用并同步到 edge-agent
+
+	// 分层配置：内置默认值 < 本地配置文件 < 远程下发 < 环境变量
+	ConfigDefaults  map[string]interface{} // 内置默认配置
+	ConfigEnvPrefix string                 // 环境变量配置前缀，如 "APP_"（APP_DB__HOST 对应 db.host），为空时不读取环境变量
+
+	// 配置同步：启动和重连后向 edge-agent 拉取配置
+	DisableConfigSync bool          // 是否关闭配置同步
+	ConfigSyncTimeout time.Duration // 拉取配置的超时时间，默认 5 秒
 }
 
 // Command 命令结构
 type Command struct {
 	Action    string                 `json:"action"`     // 命令动作：start, stop, restart, config.update, snapshot, action.xxx
-	Payload   map[string]interface{} `json:"payload"`     // 命令负载
+	Payload   map[string]interface{} `json:"payload"`    // 命令负载
 	CommandID string                 `json:"command_id"` // 命令 ID
+	Metadata  Metadata               `json:"-"`          // 命令消息的元数据（消息头）
 }
 
 // CommandResult 命令执行结果
@@ -34,11 +87,12 @@ type HeartbeatData struct {
 	Status    string                 `json:"status"` // running, stopped, error
 	Metrics   map[string]interface{} `json:"metrics,omitempty"`
 	Timestamp int64                  `json:"timestamp"`
+	Streams   []string               `json:"streams,omitempty"` // 声明的输出数据流
 }
 
 // LogData 日志数据
 type LogData struct {
-	Level     string `json:"level"`     // INFO, WARN, ERROR, DEBUG
+	Level     string `json:"level"` // INFO, WARN, ERROR, DEBUG
 	Message   string `json:"msg"`
 	Timestamp int64  `json:"timestamp"`
 }
@@ -60,12 +114,57 @@ type StatusData struct {
 
 // ConfigData 配置数据
 type ConfigData struct {
-	Config    map[string]interface{} `json:"config"`
-	Version   string                 `json:"version,omitempty"`
-	Timestamp int64                  `json:"timestamp"`
+	Config        map[string]interface{} `json:"config"`
+	Version       string                 `json:"version,omitempty"`
+	CorrelationID string                 `json:"correlation_id,omitempty"` // 关联 ID，原样带回 ConfigAck
+	Timestamp     int64                  `json:"timestamp"`
+	Metadata      Metadata               `json:"-"` // 配置消息的元数据（消息头），本地配置文件修改时为空
+}
+
+// ConfigPhase 配置应用阶段
+type ConfigPhase string
+
+const (
+	ConfigPhaseValidated  ConfigPhase = "validated"   // 配置校验通过（密钥可解密/加密）
+	ConfigPhaseSaved      ConfigPhase = "saved"       // 配置已写入文件
+	ConfigPhaseApplied    ConfigPhase = "applied"     // 配置处理函数执行成功
+	ConfigPhaseRolledBack ConfigPhase = "rolled_back" // 配置处理函数失败，配置文件已回滚
+)
+
+// 配置确认错误码
+const (
+	ConfigErrInvalidPayload = "invalid_payload" // 配置消息无法解析
+	ConfigErrInvalidConfig  = "invalid_config"  // 配置校验失败
+	ConfigErrSaveFailed     = "save_failed"     // 配置文件写入失败
+	ConfigErrApplyFailed    = "apply_failed"    // 配置处理函数返回错误
+	ConfigErrRollbackFailed = "rollback_failed" // 回滚配置文件失败
+)
+
+// ErrorDetail 结构化错误信息
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ConfigAck 配置确认
+type ConfigAck struct {
+	AppKey        string       `json:"app_key"`
+	Success       bool         `json:"success"`
+	Message       string       `json:"message"`
+	Version       string       `json:"version,omitempty"`        // 对应的配置版本
+	CorrelationID string       `json:"correlation_id,omitempty"` // 对应 ConfigData.CorrelationID
+	Phase         ConfigPhase  `json:"phase,omitempty"`          // 处理结束时到达的阶段
+	Error         *ErrorDetail `json:"error,omitempty"`
+	Timestamp     int64        `json:"timestamp"`
+}
+
+// ConfigRequest 配置拉取请求
+type ConfigRequest struct {
+	AppKey    string `json:"app_key"`
+	Version   string `json:"version,omitempty"` // 本地配置版本
+	Timestamp int64  `json:"timestamp"`         // 本地配置时间戳
 }
 
-
 // HeartbeatCallback 心跳回调函数，返回自定义数据
 type HeartbeatCallback func() map[string]interface{}
 
@@ -75,6 +174,9 @@ type CommandHandler func(cmd Command) CommandResult
 // ConfigHandler 配置更新处理函数
 type ConfigHandler func(cfg map[string]interface{}) error
 
+// ConfigMetadataHandler 配置更新处理函数，meta 为配置消息的元数据（本地配置文件修改时为空）
+type ConfigMetadataHandler func(cfg map[string]interface{}, meta Metadata) error
+
 // TopicBuilder 主题构建器
 type TopicBuilder struct {
 	appKey string
@@ -127,3 +229,22 @@ func (tb *TopicBuilder) ConfigAck() string {
 	return "app." + tb.appKey + ".config.ack"
 }
 
+// ConfigGet 配置拉取主题（Request-Reply）
+func (tb *TopicBuilder) ConfigGet() string {
+	return "app." + tb.appKey + ".config.get"
+}
+
+// ConfigReport 本地配置上报主题（配置文件被本地修改后同步到 edge-agent）
+func (tb *TopicBuilder) ConfigReport() string {
+	return "app." + tb.appKey + ".config.report"
+}
+
+// Hello 协议协商主题（Request-Reply）
+func (tb *TopicBuilder) Hello() string {
+	return "app." + tb.appKey + ".hello"
+}
+
+// Data 数据流主题
+func (tb *TopicBuilder) Data(stream string) string {
+	return "app." + tb.appKey + ".data." + stream
+}
@@ -0,0 +1,90 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// v311Backend 基于 paho.mqtt.golang 的 MQTT 3.1.1 实现
+// 3.1.1 没有消息属性，消息头、响应主题和关联数据都不会传输
+type v311Backend struct {
+	t      *Transport
+	client pahomqtt.Client
+}
+
+// newV311Backend 创建 MQTT 3.1.1 客户端
+func newV311Backend(t *Transport) (backend, error) {
+	b := &v311Backend{t: t}
+
+	opts := pahomqtt.NewClientOptions()
+	for _, broker := range t.opts.Brokers {
+		opts.AddBroker(broker)
+	}
+	opts.SetClientID(t.opts.ClientID)
+	opts.SetProtocolVersion(4)
+	opts.SetUsername(t.opts.Username)
+	opts.SetPassword(t.opts.Password)
+	if t.opts.TLSConfig != nil {
+		opts.SetTLSConfig(t.opts.TLSConfig)
+	}
+	opts.SetKeepAlive(t.opts.KeepAlive)
+	opts.SetConnectTimeout(t.opts.ConnectTimeout)
+	opts.SetAutoReconnect(true)
+	opts.SetMaxReconnectInterval(t.opts.ConnectRetry)
+	opts.SetCleanSession(true)
+	opts.SetOnConnectHandler(func(pahomqtt.Client) {
+		t.onConnect()
+	})
+	opts.SetConnectionLostHandler(func(_ pahomqtt.Client, err error) {
+		t.onDisconnect(err)
+	})
+	opts.SetDefaultPublishHandler(func(_ pahomqtt.Client, m pahomqtt.Message) {
+		t.dispatch(&inboundMessage{topic: m.Topic(), payload: m.Payload()})
+	})
+
+	b.client = pahomqtt.NewClient(opts)
+	return b, nil
+}
+
+// waitToken 等待操作完成
+func waitToken(ctx context.Context, token pahomqtt.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// connect 连接 broker
+func (b *v311Backend) connect(ctx context.Context) error {
+	return waitToken(ctx, b.client.Connect())
+}
+
+// publish 发布消息
+func (b *v311Backend) publish(ctx context.Context, msg *outboundMessage) error {
+	if msg.responseTopic != "" {
+		return ErrRequestNotSupported
+	}
+	if !b.client.IsConnectionOpen() {
+		return errors.New("mqtt: not connected")
+	}
+	return waitToken(ctx, b.client.Publish(msg.topic, msg.qos, false, msg.payload))
+}
+
+// subscribe 订阅（消息由默认处理函数统一分发）；MQTT 3.1.1 没有订阅标识符，忽略 id
+func (b *v311Backend) subscribe(ctx context.Context, filter string, qos byte, id int) error {
+	return waitToken(ctx, b.client.Subscribe(filter, qos, nil))
+}
+
+// unsubscribe 取消订阅
+func (b *v311Backend) unsubscribe(ctx context.Context, filter string) error {
+	return waitToken(ctx, b.client.Unsubscribe(filter))
+}
+
+// close 断开连接
+func (b *v311Backend) close() {
+	b.client.Disconnect(250)
+}
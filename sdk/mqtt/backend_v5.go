@@ -0,0 +1,223 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/eclipse/paho.golang/paho"
+	"golang.org/x/net/proxy"
+)
+
+// v5Backend 基于 paho.golang（autopaho）的 MQTT 5 实现
+type v5Backend struct {
+	t      *Transport
+	cfg    autopaho.ClientConfig
+	cm     *autopaho.ConnectionManager
+	cancel context.CancelFunc
+	subIDs atomic.Bool // broker 是否支持订阅标识符
+	pubIDs publishIDs  // 收到的 PUBLISH 报文的全部订阅标识符（见 subid.go）
+}
+
+// newV5Backend 创建 MQTT 5 客户端
+func newV5Backend(t *Transport) (backend, error) {
+	urls := make([]*url.URL, 0, len(t.opts.Brokers))
+	for _, broker := range t.opts.Brokers {
+		u, err := url.Parse(broker)
+		if err != nil {
+			return nil, fmt.Errorf("invalid broker url %q: %w", broker, err)
+		}
+		urls = append(urls, u)
+	}
+
+	b := &v5Backend{t: t}
+	b.cfg = autopaho.ClientConfig{
+		AttemptConnection:             b.attemptConnection,
+		ServerUrls:                    urls,
+		TlsCfg:                        t.opts.TLSConfig,
+		KeepAlive:                     uint16(t.opts.KeepAlive.Seconds()),
+		CleanStartOnInitialConnection: true,
+		ConnectRetryDelay:             t.opts.ConnectRetry,
+		ConnectTimeout:                t.opts.ConnectTimeout,
+		ConnectUsername:               t.opts.Username,
+		ConnectPassword:               []byte(t.opts.Password),
+		OnConnectionUp: func(_ *autopaho.ConnectionManager, connack *paho.Connack) {
+			b.subIDs.Store(connack.Properties == nil || connack.Properties.SubIDAvailable)
+			t.onConnect()
+		},
+		OnConnectError: func(err error) {
+			t.onDisconnect(err)
+		},
+		ClientConfig: paho.ClientConfig{
+			ClientID: t.opts.ClientID,
+			OnPublishReceived: []func(paho.PublishReceived) (bool, error){
+				func(pr paho.PublishReceived) (bool, error) {
+					b.handlePublish(pr.Packet)
+					return true, nil
+				},
+			},
+			OnClientError: func(err error) {
+				t.onDisconnect(err)
+			},
+			OnServerDisconnect: func(d *paho.Disconnect) {
+				t.onDisconnect(fmt.Errorf("server disconnect (reason %d)", d.ReasonCode))
+			},
+		},
+	}
+
+	// WebSocket 连接由 autopaho 建立，只使用 paho 解析的订阅标识符（多个时为最后一个）
+	for _, u := range urls {
+		if !isTCPScheme(u.Scheme) && !isTLSScheme(u.Scheme) {
+			b.cfg.AttemptConnection = nil
+			break
+		}
+	}
+
+	return b, nil
+}
+
+// isTCPScheme 是否为 TCP 连接的 broker 地址
+func isTCPScheme(scheme string) bool {
+	switch strings.ToLower(scheme) {
+	case "mqtt", "tcp", "":
+		return true
+	}
+	return false
+}
+
+// isTLSScheme 是否为 TLS 连接的 broker 地址
+func isTLSScheme(scheme string) bool {
+	switch strings.ToLower(scheme) {
+	case "ssl", "tls", "mqtts", "mqtt+ssl", "tcps":
+		return true
+	}
+	return false
+}
+
+// attemptConnection 建立 TCP/TLS 连接（与 autopaho 默认行为相同），并扫描收到的 PUBLISH 报文的订阅标识符
+func (b *v5Backend) attemptConnection(ctx context.Context, cfg autopaho.ClientConfig, u *url.URL) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+
+	var conn net.Conn
+	var err error
+	switch {
+	case os.Getenv("all_proxy") != "":
+		conn, err = proxy.FromEnvironment().Dial("tcp", u.Host)
+		if err == nil && isTLSScheme(u.Scheme) {
+			tlsConn := tls.Client(conn, cfg.TlsCfg)
+			if err = tlsConn.HandshakeContext(ctx); err != nil {
+				conn.Close()
+			}
+			conn = tlsConn
+		}
+	case isTLSScheme(u.Scheme):
+		d := tls.Dialer{Config: cfg.TlsCfg}
+		conn, err = d.DialContext(ctx, "tcp", u.Host)
+	default:
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", u.Host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b.pubIDs.reset()
+	return packets.NewThreadSafeConn(newIDConn(conn, &b.pubIDs)), nil
+}
+
+// connect 连接 broker，等待首次连接成功
+func (b *v5Backend) connect(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(context.Background())
+	cm, err := autopaho.NewConnection(runCtx, b.cfg)
+	if err != nil {
+		cancel()
+		return err
+	}
+	b.cm = cm
+	b.cancel = cancel
+
+	return cm.AwaitConnection(ctx)
+}
+
+// handlePublish 转换收到的消息
+func (b *v5Backend) handlePublish(p *paho.Publish) {
+	in := &inboundMessage{
+		topic:   p.Topic,
+		payload: p.Payload,
+	}
+	// 每个报文都要取回记录，保持与收到的报文对齐
+	ids, scanned := b.pubIDs.take(p.QoS, p.PacketID, p.Topic)
+	if scanned {
+		in.subscriptionIDs = ids
+	}
+	if p.Properties != nil {
+		in.responseTopic = p.Properties.ResponseTopic
+		in.correlationData = p.Properties.CorrelationData
+		in.contentType = p.Properties.ContentType
+		if !scanned && p.Properties.SubscriptionIdentifier != nil {
+			in.subscriptionIDs = []int{*p.Properties.SubscriptionIdentifier}
+		}
+		for _, prop := range p.Properties.User {
+			in.userProperties = append(in.userProperties, [2]string{prop.Key, prop.Value})
+		}
+	}
+	b.t.dispatch(in)
+}
+
+// publish 发布消息
+func (b *v5Backend) publish(ctx context.Context, msg *outboundMessage) error {
+	props := &paho.PublishProperties{
+		ResponseTopic:   msg.responseTopic,
+		CorrelationData: msg.correlationData,
+		ContentType:     msg.contentType,
+	}
+	for _, kv := range msg.userProperties {
+		props.User.Add(kv[0], kv[1])
+	}
+
+	_, err := b.cm.Publish(ctx, &paho.Publish{
+		Topic:      msg.topic,
+		QoS:        msg.qos,
+		Payload:    msg.payload,
+		Properties: props,
+	})
+	return err
+}
+
+// subscribe 订阅，broker 支持时带上订阅标识符
+func (b *v5Backend) subscribe(ctx context.Context, filter string, qos byte, id int) error {
+	sub := &paho.Subscribe{
+		Subscriptions: []paho.SubscribeOptions{{Topic: filter, QoS: qos}},
+	}
+	if b.subIDs.Load() {
+		sub.Properties = &paho.SubscribeProperties{SubscriptionIdentifier: &id}
+	}
+	_, err := b.cm.Subscribe(ctx, sub)
+	return err
+}
+
+// unsubscribe 取消订阅
+func (b *v5Backend) unsubscribe(ctx context.Context, filter string) error {
+	_, err := b.cm.Unsubscribe(ctx, &paho.Unsubscribe{Topics: []string{filter}})
+	return err
+}
+
+// close 断开连接
+func (b *v5Backend) close() {
+	if b.cm != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), b.t.opts.ConnectTimeout)
+		defer cancel()
+		b.cm.Disconnect(ctx)
+	}
+	if b.cancel != nil {
+		b.cancel()
+	}
+}
@@ -0,0 +1,258 @@
+package mqtt
+
+import (
+	"net"
+	"sync"
+)
+
+// paho.golang 解析 PUBLISH 时只保留最后一个订阅标识符，而 broker 可以将匹配多个订阅的消息
+// 合并为一个携带全部订阅标识符的报文发送（MQTT 5 规范 3.3.4）。
+// idConn 在 paho 读取之前扫描收到的字节流，记录每个 PUBLISH 报文的全部订阅标识符，
+// handlePublish 按 QoS、报文标识符和主题取回，分发时投递给每个匹配的订阅
+
+// maxPublishHeader 为解析订阅标识符缓存的 PUBLISH 可变报头上限，超过时放弃该报文（回退到 paho 解析的标识符）
+const maxPublishHeader = 256 * 1024
+
+// maxPendingPublishes 等待 handlePublish 取回的 PUBLISH 记录上限
+const maxPendingPublishes = 1024
+
+// publishIDs 收到的 PUBLISH 报文及其订阅标识符，按到达顺序排列
+type publishIDs struct {
+	mu    sync.Mutex
+	queue []publishIDEntry
+}
+
+// publishIDEntry 一个 PUBLISH 报文的订阅标识符
+type publishIDEntry struct {
+	qos      byte
+	packetID uint16
+	topic    string
+	ids      []int
+	complete bool // 属性解析完整，ids 可用
+}
+
+// push 记录报文，超过上限时丢弃最旧的记录
+func (q *publishIDs) push(e publishIDEntry) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.queue) >= maxPendingPublishes {
+		q.queue = q.queue[1:]
+	}
+	q.queue = append(q.queue, e)
+}
+
+// reset 清空记录（建立新连接时）
+func (q *publishIDs) reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queue = nil
+}
+
+// take 取回报文的订阅标识符。paho 按到达顺序处理报文，但可能丢弃重复的 QoS 2 报文，
+// 因此跳过不匹配的旧记录；找不到或解析不完整时返回 false
+func (q *publishIDs) take(qos byte, packetID uint16, topic string) ([]int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.queue) > 0 {
+		e := q.queue[0]
+		q.queue = q.queue[1:]
+		if e.qos != qos || e.packetID != packetID {
+			continue
+		}
+		// QoS 0 没有报文标识符，按主题匹配（使用主题别名时报文中的主题可能为空）
+		if qos == 0 && e.topic != topic && e.topic != "" {
+			continue
+		}
+		return e.ids, e.complete
+	}
+	return nil, false
+}
+
+// idConn 扫描读取的字节流，记录 PUBLISH 报文的订阅标识符
+type idConn struct {
+	net.Conn
+	scanner packetScanner
+}
+
+// newIDConn 包装连接
+func newIDConn(conn net.Conn, ids *publishIDs) *idConn {
+	return &idConn{Conn: conn, scanner: packetScanner{onPublish: ids.push}}
+}
+
+func (c *idConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.scanner.feed(p[:n])
+	}
+	return n, err
+}
+
+// 报文扫描状态
+const (
+	scanFixedHeader = iota
+	scanRemainingLength
+	scanBody
+)
+
+// packetScanner 增量解析 MQTT 报文边界，只解析 PUBLISH 的可变报头
+type packetScanner struct {
+	onPublish func(publishIDEntry)
+
+	state     int
+	header    byte
+	remaining int // 剩余长度
+	shift     uint
+	lenBytes  int
+	left      int    // 报文体中尚未读取的字节数
+	body      []byte // 已缓存的 PUBLISH 可变报头
+	parsed    bool   // 当前报文不需要（或已完成）解析
+	broken    bool   // 报文格式错误，停止扫描（paho 会断开连接）
+}
+
+// feed 处理读取到的字节
+func (s *packetScanner) feed(p []byte) {
+	for len(p) > 0 && !s.broken {
+		switch s.state {
+		case scanFixedHeader:
+			s.header = p[0]
+			p = p[1:]
+			s.remaining, s.shift, s.lenBytes = 0, 0, 0
+			s.state = scanRemainingLength
+		case scanRemainingLength:
+			b := p[0]
+			p = p[1:]
+			s.remaining |= int(b&0x7f) << s.shift
+			s.shift += 7
+			s.lenBytes++
+			if b&0x80 != 0 {
+				if s.lenBytes == 4 {
+					s.broken = true
+				}
+				continue
+			}
+			s.left = s.remaining
+			s.body = s.body[:0]
+			s.parsed = s.header>>4 != 3 // 只解析 PUBLISH
+			s.state = scanBody
+			if s.left == 0 {
+				s.finish()
+			}
+		case scanBody:
+			n := min(len(p), s.left)
+			if !s.parsed {
+				s.body = append(s.body, p[:n]...)
+				if e, ok := parsePublishHeader(s.header, s.body); ok {
+					e.complete = true
+					s.onPublish(e)
+					s.parsed = true
+				} else if len(s.body) > maxPublishHeader {
+					s.onPublish(e)
+					s.parsed = true
+				}
+			}
+			s.left -= n
+			p = p[n:]
+			if s.left == 0 {
+				s.finish()
+			}
+		}
+	}
+}
+
+// finish 当前报文结束，准备读取下一个报文
+func (s *packetScanner) finish() {
+	if !s.parsed {
+		// 报文不完整或格式错误，paho 会报告错误；仍记录报文，避免后续报文错位
+		e, _ := parsePublishHeader(s.header, s.body)
+		s.onPublish(e)
+	}
+	s.state = scanFixedHeader
+	if cap(s.body) > 4096 {
+		s.body = nil
+	}
+}
+
+// parsePublishHeader 解析 PUBLISH 可变报头中的主题、报文标识符和全部订阅标识符
+// 数据不足以解析完整的属性时返回 false
+func parsePublishHeader(header byte, b []byte) (publishIDEntry, bool) {
+	e := publishIDEntry{qos: (header >> 1) & 0x03}
+	if len(b) < 2 {
+		return e, false
+	}
+	off := 2 + (int(b[0])<<8 | int(b[1]))
+	if len(b) < off {
+		return e, false
+	}
+	e.topic = string(b[2:off])
+	if e.qos > 0 {
+		if len(b) < off+2 {
+			return e, false
+		}
+		e.packetID = uint16(b[off])<<8 | uint16(b[off+1])
+		off += 2
+	}
+
+	propsLen, n, ok := decodeVarInt(b[off:])
+	if !ok {
+		return e, false
+	}
+	off += n
+	if len(b) < off+propsLen {
+		return e, false
+	}
+	props := b[off : off+propsLen]
+
+	for len(props) > 0 {
+		id := props[0]
+		props = props[1:]
+		var size int
+		switch id {
+		case 0x01: // Payload Format Indicator
+			size = 1
+		case 0x02: // Message Expiry Interval
+			size = 4
+		case 0x23: // Topic Alias
+			size = 2
+		case 0x03, 0x08, 0x09: // Content Type、Response Topic、Correlation Data
+			if len(props) < 2 {
+				return e, true
+			}
+			size = 2 + (int(props[0])<<8 | int(props[1]))
+		case 0x26: // User Property（两个字符串）
+			if len(props) < 2 {
+				return e, true
+			}
+			size = 2 + (int(props[0])<<8 | int(props[1]))
+			if len(props) < size+2 {
+				return e, true
+			}
+			size += 2 + (int(props[size])<<8 | int(props[size+1]))
+		case 0x0b: // Subscription Identifier
+			v, n, ok := decodeVarInt(props)
+			if !ok {
+				return e, true
+			}
+			e.ids = append(e.ids, v)
+			size = n
+		default:
+			return e, true // 未知属性，无法继续解析
+		}
+		if len(props) < size {
+			return e, true
+		}
+		props = props[size:]
+	}
+	return e, true
+}
+
+// decodeVarInt 解析变长整数，返回值和占用的字节数
+func decodeVarInt(b []byte) (int, int, bool) {
+	v := 0
+	for i := 0; i < 4 && i < len(b); i++ {
+		v |= int(b[i]&0x7f) << (7 * i)
+		if b[i]&0x80 == 0 {
+			return v, i + 1, true
+		}
+	}
+	return 0, 0, false
+}
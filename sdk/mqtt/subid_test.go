@@ -0,0 +1,171 @@
+package mqtt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// encodeVarInt 编码变长整数
+func encodeVarInt(v int) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v > 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+// encodeString 编码 MQTT 字符串（两字节长度前缀）
+func encodeString(s string) []byte {
+	return append([]byte{byte(len(s) >> 8), byte(len(s))}, s...)
+}
+
+// encodePublish 编码 MQTT 5 PUBLISH 报文，props 为编码后的属性
+func encodePublish(qos byte, packetID uint16, topic string, props []byte, payload string) []byte {
+	body := encodeString(topic)
+	if qos > 0 {
+		body = append(body, byte(packetID>>8), byte(packetID))
+	}
+	body = append(body, encodeVarInt(len(props))...)
+	body = append(body, props...)
+	body = append(body, payload...)
+
+	packet := []byte{0x30 | qos<<1}
+	packet = append(packet, encodeVarInt(len(body))...)
+	return append(packet, body...)
+}
+
+// subIDProps 编码订阅标识符属性
+func subIDProps(ids ...int) []byte {
+	var b []byte
+	for _, id := range ids {
+		b = append(b, 0x0b)
+		b = append(b, encodeVarInt(id)...)
+	}
+	return b
+}
+
+func TestParsePublishHeader(t *testing.T) {
+	var props []byte
+	props = append(props, 0x01, 1)           // Payload Format Indicator
+	props = append(props, 0x02, 0, 0, 0, 60) // Message Expiry Interval
+	props = append(props, 0x03)              // Content Type
+	props = append(props, encodeString("application/json")...)
+	props = append(props, 0x09) // Correlation Data
+	props = append(props, encodeString(string(make([]byte, 300)))...)
+	props = append(props, 0x26) // User Property
+	props = append(props, encodeString("k")...)
+	props = append(props, encodeString("v")...)
+	props = append(props, subIDProps(3, 200, 16384)...)
+
+	tests := []struct {
+		name   string
+		packet []byte
+		want   publishIDEntry
+	}{
+		{
+			name:   "qos 0 multiple identifiers",
+			packet: encodePublish(0, 0, "c/d", subIDProps(1, 2), "x"),
+			want:   publishIDEntry{topic: "c/d", ids: []int{1, 2}},
+		},
+		{
+			name:   "qos 1 with other properties",
+			packet: encodePublish(1, 258, "a/b", props, "payload"),
+			want:   publishIDEntry{qos: 1, packetID: 258, topic: "a/b", ids: []int{3, 200, 16384}},
+		},
+		{
+			name:   "no properties",
+			packet: encodePublish(2, 7, "a", nil, ""),
+			want:   publishIDEntry{qos: 2, packetID: 7, topic: "a"},
+		},
+		{
+			name:   "topic length sets bit 1",
+			packet: encodePublish(0, 0, "ab", subIDProps(5), ""),
+			want:   publishIDEntry{topic: "ab", ids: []int{5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, n, _ := decodeVarInt(tt.packet[1:])
+			got, ok := parsePublishHeader(tt.packet[0], tt.packet[1+n:])
+			if !ok {
+				t.Fatal("parsePublishHeader() ok = false")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePublishHeader() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePublishHeaderIncomplete(t *testing.T) {
+	packet := encodePublish(1, 9, "a/b", subIDProps(1, 2), "")
+	_, n, _ := decodeVarInt(packet[1:])
+	body := packet[1+n:]
+	for i := 0; i < len(body); i++ {
+		if _, ok := parsePublishHeader(packet[0], body[:i]); ok {
+			t.Errorf("parsePublishHeader(%d bytes) ok = true, want false", i)
+		}
+	}
+}
+
+func TestPacketScanner(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write([]byte{0x20, 3, 0, 0, 0}) // CONNACK
+	stream.Write([]byte{0xd0, 0})          // PINGRESP
+	stream.Write(encodePublish(0, 0, "c/d", subIDProps(1, 2), "hello"))
+	stream.Write([]byte{0x40, 2, 0, 1}) // PUBACK
+	stream.Write(encodePublish(1, 42, "a/b", subIDProps(7), string(make([]byte, 200))))
+	stream.Write(encodePublish(2, 43, "a/b", nil, ""))
+
+	want := []publishIDEntry{
+		{topic: "c/d", ids: []int{1, 2}, complete: true},
+		{qos: 1, packetID: 42, topic: "a/b", ids: []int{7}, complete: true},
+		{qos: 2, packetID: 43, topic: "a/b", complete: true},
+	}
+
+	for _, size := range []int{1, 2, 7, stream.Len()} {
+		var got []publishIDEntry
+		s := packetScanner{onPublish: func(e publishIDEntry) { got = append(got, e) }}
+		data := stream.Bytes()
+		for len(data) > 0 {
+			n := min(size, len(data))
+			s.feed(data[:n])
+			data = data[n:]
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("feed %d bytes at a time: got %+v, want %+v", size, got, want)
+		}
+	}
+}
+
+func TestPublishIDsTake(t *testing.T) {
+	var q publishIDs
+	q.push(publishIDEntry{qos: 2, packetID: 1, topic: "a", ids: []int{1}, complete: true})
+	q.push(publishIDEntry{qos: 1, packetID: 2, topic: "a", ids: []int{2}, complete: true})
+	q.push(publishIDEntry{topic: "b", ids: []int{3}, complete: true})
+	q.push(publishIDEntry{topic: "c", ids: []int{4}})
+
+	// 重复的 QoS 2 报文被 paho 丢弃，跳过其记录
+	if ids, ok := q.take(1, 2, "a"); !ok || !reflect.DeepEqual(ids, []int{2}) {
+		t.Errorf("take(1, 2, a) = %v, %v", ids, ok)
+	}
+	if ids, ok := q.take(0, 0, "b"); !ok || !reflect.DeepEqual(ids, []int{3}) {
+		t.Errorf("take(0, 0, b) = %v, %v", ids, ok)
+	}
+	// 解析不完整的记录不可用
+	if _, ok := q.take(0, 0, "c"); ok {
+		t.Error("take(0, 0, c) ok = true for incomplete entry")
+	}
+	if _, ok := q.take(0, 0, "d"); ok {
+		t.Error("take on empty queue ok = true")
+	}
+}
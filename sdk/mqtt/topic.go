@@ -0,0 +1,49 @@
+package mqtt
+
+import "strings"
+
+// subjectToTopic 将 NATS 风格主题映射为 MQTT 主题：
+// 点分隔改为斜杠分隔，通配符 * 映射为 +，> 映射为 #
+// 例如 app.camera.heartbeat -> app/camera/heartbeat，app.*.heartbeat -> app/+/heartbeat
+func subjectToTopic(subject string) string {
+	tokens := strings.Split(subject, ".")
+	for i, token := range tokens {
+		switch token {
+		case "*":
+			tokens[i] = "+"
+		case ">":
+			tokens[i] = "#"
+		}
+	}
+	return strings.Join(tokens, "/")
+}
+
+// topicToSubject 将 MQTT 主题映射回 NATS 风格主题
+func topicToSubject(topic string) string {
+	return strings.ReplaceAll(topic, "/", ".")
+}
+
+// topicMatches 判断 MQTT 主题是否匹配订阅过滤器（支持 + 和 # 通配符）
+func topicMatches(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+
+	return len(filterLevels) == len(topicLevels)
+}
+
+// sharedTopic 共享订阅主题（用于队列订阅，同一组中只有一个订阅者收到消息）
+func sharedTopic(queue, filter string) string {
+	return "$share/" + queue + "/" + filter
+}
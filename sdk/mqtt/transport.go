@@ -0,0 +1,700 @@
+// Package mqtt 提供基于 MQTT 3.1.1/5 的 sdk.Transport 实现，
+// 用于只运行 Mosquitto 等 MQTT broker、没有 NATS 的网关。
+//
+//	transport, err := mqtt.New(mqtt.Options{Brokers: []string{"mqtt://127.0.0.1:1883"}})
+//	client, err := sdk.NewClient(sdk.Options{AppKey: "app.camera", Transport: transport})
+package mqtt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/punk-one/edge-app-sdk/sdk"
+	"github.com/sirupsen/logrus"
+)
+
+// 协议版本
+const (
+	ProtocolV311 = 4 // MQTT 3.1.1
+	ProtocolV5   = 5 // MQTT 5
+)
+
+// correlationHeader 请求消息中保存 MQTT 5 Correlation Data（base64）的消息头，回复时使用
+const correlationHeader = "Mqtt-Correlation-Data"
+
+// ErrRequestNotSupported MQTT 3.1.1 没有响应主题和关联数据，不支持 Request-Reply
+var ErrRequestNotSupported = errors.New("mqtt: request-reply requires MQTT 5")
+
+// Options MQTT 传输层选项
+type Options struct {
+	Brokers         []string       // broker 地址，如 "mqtt://127.0.0.1:1883"、"tls://broker:8883"
+	ClientID        string         // 客户端 ID，默认随机生成
+	ProtocolVersion int            // 协议版本（ProtocolV311/ProtocolV5），默认 MQTT 5
+	Username        string         // 用户名
+	Password        string         // 密码
+	TLSConfig       *tls.Config    // TLS 配置
+	KeepAlive       time.Duration  // 心跳保活间隔，默认 30 秒
+	ConnectTimeout  time.Duration  // 连接超时，默认 10 秒
+	ConnectRetry    time.Duration  // 断线重连间隔，默认 1 秒
+	Logger          *logrus.Logger // 日志（慢消费者丢弃消息、重新订阅失败等），默认使用 logrus 标准 logger
+
+	// QoS 选择：按流设置，键为主题的最后部分（如 "heartbeat"、"logs"、"cmd"、"cmd.result"），
+	// 匹配时取最长的后缀，未匹配的使用 DefaultQoS
+	DefaultQoS byte
+	QoS        map[string]byte
+}
+
+// inboundMessage 收到的 MQTT 消息
+type inboundMessage struct {
+	topic           string
+	payload         []byte
+	responseTopic   string
+	correlationData []byte
+	contentType     string
+	userProperties  [][2]string
+	subscriptionIDs []int // MQTT 5 订阅标识符，broker 未提供时为空
+}
+
+// outboundMessage 待发送的 MQTT 消息
+type outboundMessage struct {
+	topic           string
+	qos             byte
+	payload         []byte
+	responseTopic   string
+	correlationData []byte
+	contentType     string
+	userProperties  [][2]string
+}
+
+// backend MQTT 客户端库适配（3.1.1 和 5 使用不同的实现）
+type backend interface {
+	connect(ctx context.Context) error
+	publish(ctx context.Context, msg *outboundMessage) error
+	subscribe(ctx context.Context, filter string, qos byte, id int) error
+	unsubscribe(ctx context.Context, filter string) error
+	close()
+}
+
+// pendingLimit 每个订阅待处理消息的上限，超过后丢弃新消息（慢消费者）
+const pendingLimit = 4096
+
+// brokerSubscription broker 上的一个订阅，同一主题的多个本地订阅共用
+// MQTT 5 下使用订阅标识符订阅，收到的消息按标识符路由到对应的 broker 订阅，
+// 避免普通订阅收到队列订阅的消息副本，以及重叠的订阅收到重复消息
+type brokerSubscription struct {
+	id     int    // 订阅标识符
+	topic  string // 实际订阅的主题（队列订阅时为共享订阅主题）
+	filter string // 用于匹配消息的主题过滤器
+	shared bool   // 共享订阅（队列订阅）
+	qos    byte
+	subs   []*subscription
+	next   int // 共享订阅下一次投递的本地订阅
+}
+
+// route 追加应收到消息的本地订阅：共享订阅的一份消息只投递给其中一个本地订阅（轮流），普通订阅全部投递
+func (b *brokerSubscription) route(targets []*subscription) []*subscription {
+	if len(b.subs) == 0 {
+		return targets
+	}
+	if b.shared {
+		b.next = (b.next + 1) % len(b.subs)
+		return append(targets, b.subs[b.next])
+	}
+	return append(targets, b.subs...)
+}
+
+// subscription 本地订阅记录
+// 每个订阅在独立的 goroutine 中按顺序处理消息（与 NATS 一致），
+// 避免处理函数中发布 QoS>0 消息时阻塞 MQTT 客户端的接收循环
+type subscription struct {
+	t       *Transport
+	broker  *brokerSubscription
+	handler sdk.MessageHandler
+	msgs    chan *sdk.Message
+	done    chan struct{}
+	once    sync.Once
+}
+
+// newSubscription 创建订阅并启动处理 goroutine
+func newSubscription(t *Transport, broker *brokerSubscription, handler sdk.MessageHandler) *subscription {
+	sub := &subscription{
+		t:       t,
+		broker:  broker,
+		handler: handler,
+		msgs:    make(chan *sdk.Message, pendingLimit),
+		done:    make(chan struct{}),
+	}
+	go sub.run()
+	return sub
+}
+
+// run 按顺序处理消息，直到订阅取消
+func (s *subscription) run() {
+	for {
+		select {
+		case msg := <-s.msgs:
+			s.handler(msg)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// deliver 投递消息，队列已满时丢弃
+func (s *subscription) deliver(msg *sdk.Message) {
+	select {
+	case s.msgs <- msg:
+	case <-s.done:
+	default:
+		s.t.opts.Logger.Warnf("MQTT slow consumer on %s, message dropped", s.broker.topic)
+	}
+}
+
+// stop 停止处理 goroutine
+func (s *subscription) stop() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// Unsubscribe 取消订阅
+func (s *subscription) Unsubscribe() error {
+	return s.t.unsubscribe(s)
+}
+
+// Transport 基于 MQTT 的 sdk.Transport 实现
+type Transport struct {
+	opts    Options
+	backend backend
+
+	mu               sync.RWMutex
+	connected        bool
+	everConnected    bool
+	closed           bool
+	brokerSubs       map[string]*brokerSubscription // 以订阅主题为键
+	brokerSubIDs     map[int]*brokerSubscription    // 以订阅标识符为键
+	nextBrokerSubID  int
+	reconnectHandler func()
+	stateHandler     func(state sdk.ConnState, err error)
+	firstConnect     chan struct{} // 首次连接成功时关闭
+
+	// 连接统计
+	inMsgs, outMsgs, inBytes, outBytes, reconnects atomic.Uint64
+
+	inbox     string // Request-Reply 的响应主题
+	inboxOnce sync.Once
+	inboxErr  error
+	pendingMu sync.Mutex
+	pending   map[string]chan *sdk.Message
+}
+
+// New 创建 MQTT 传输层并连接 broker
+func New(opts Options) (*Transport, error) {
+	if len(opts.Brokers) == 0 {
+		opts.Brokers = []string{"mqtt://127.0.0.1:1883"}
+	}
+	if opts.ClientID == "" {
+		opts.ClientID = "edge-app-" + randomHex(6)
+	}
+	if opts.ProtocolVersion == 0 {
+		opts.ProtocolVersion = ProtocolV5
+	}
+	if opts.KeepAlive == 0 {
+		opts.KeepAlive = 30 * time.Second
+	}
+	if opts.ConnectTimeout == 0 {
+		opts.ConnectTimeout = 10 * time.Second
+	}
+	if opts.ConnectRetry == 0 {
+		opts.ConnectRetry = time.Second
+	}
+	if opts.Logger == nil {
+		opts.Logger = logrus.StandardLogger()
+	}
+
+	t := &Transport{
+		opts:    opts,
+		pending: make(map[string]chan *sdk.Message),
+		inbox:   "_inbox/" + opts.ClientID,
+
+		brokerSubs:   make(map[string]*brokerSubscription),
+		brokerSubIDs: make(map[int]*brokerSubscription),
+
+		firstConnect: make(chan struct{}),
+	}
+
+	var err error
+	switch opts.ProtocolVersion {
+	case ProtocolV5:
+		t.backend, err = newV5Backend(t)
+	case ProtocolV311:
+		t.backend, err = newV311Backend(t)
+	default:
+		return nil, fmt.Errorf("unsupported MQTT protocol version: %d", opts.ProtocolVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.ConnectTimeout)
+	defer cancel()
+	if err := t.backend.connect(ctx); err != nil {
+		t.backend.close()
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+
+	// 等待连接回调完成（标记连接状态）
+	select {
+	case <-t.firstConnect:
+	case <-ctx.Done():
+		t.backend.close()
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", ctx.Err())
+	}
+
+	return t, nil
+}
+
+// qosFor 获取主题对应的 QoS
+func (t *Transport) qosFor(subject string) byte {
+	qos := t.opts.DefaultQoS
+	longest := -1
+	for stream, q := range t.opts.QoS {
+		if (subject == stream || strings.HasSuffix(subject, "."+stream)) && len(stream) > longest {
+			qos = q
+			longest = len(stream)
+		}
+	}
+	return qos
+}
+
+// Publish 发布消息
+func (t *Transport) Publish(msg *sdk.Message) error {
+	out := &outboundMessage{
+		topic:   subjectToTopic(msg.Subject),
+		qos:     t.qosFor(msg.Subject),
+		payload: msg.Data,
+	}
+	if msg.Reply != "" && t.opts.ProtocolVersion == ProtocolV5 {
+		// Reply 为 MQTT 响应主题原文，不做映射
+		out.responseTopic = msg.Reply
+	}
+	t.applyHeader(out, msg.Header)
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.opts.ConnectTimeout)
+	defer cancel()
+	return t.publish(ctx, out)
+}
+
+// publish 发布消息并更新统计
+func (t *Transport) publish(ctx context.Context, out *outboundMessage) error {
+	if err := t.backend.publish(ctx, out); err != nil {
+		return err
+	}
+	t.outMsgs.Add(1)
+	t.outBytes.Add(uint64(len(out.payload)))
+	return nil
+}
+
+// applyHeader 将消息头映射为 MQTT 5 属性（Content-Type 和 User Properties），MQTT 3.1.1 忽略
+func (t *Transport) applyHeader(out *outboundMessage, header sdk.Header) {
+	if t.opts.ProtocolVersion != ProtocolV5 {
+		return
+	}
+	for key, values := range header {
+		switch key {
+		case correlationHeader:
+			continue
+		case sdk.HeaderContentType:
+			out.contentType = header.Get(key)
+		default:
+			for _, v := range values {
+				out.userProperties = append(out.userProperties, [2]string{key, v})
+			}
+		}
+	}
+}
+
+// Subscribe 订阅主题
+func (t *Transport) Subscribe(subject string, handler sdk.MessageHandler) (sdk.Subscription, error) {
+	filter := subjectToTopic(subject)
+	return t.subscribe(filter, filter, false, t.qosFor(subject), handler)
+}
+
+// QueueSubscribe 队列订阅（使用 MQTT 共享订阅 $share/<queue>/<topic>）
+// 同一连接上同一队列组的多个订阅共用一个共享订阅，每条消息只投递给其中一个
+func (t *Transport) QueueSubscribe(subject, queue string, handler sdk.MessageHandler) (sdk.Subscription, error) {
+	filter := subjectToTopic(subject)
+	return t.subscribe(filter, sharedTopic(queue, filter), true, t.qosFor(subject), handler)
+}
+
+// subscribe 记录订阅，主题第一次被订阅时发送到 broker，重连后自动重新订阅
+func (t *Transport) subscribe(filter, topic string, shared bool, qos byte, handler sdk.MessageHandler) (sdk.Subscription, error) {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil, errors.New("mqtt: transport closed")
+	}
+	broker, exists := t.brokerSubs[topic]
+	if !exists {
+		t.nextBrokerSubID++
+		broker = &brokerSubscription{id: t.nextBrokerSubID, topic: topic, filter: filter, shared: shared, qos: qos}
+		t.brokerSubs[topic] = broker
+		t.brokerSubIDs[broker.id] = broker
+	}
+	sub := newSubscription(t, broker, handler)
+	broker.subs = append(broker.subs, sub)
+	connected := t.connected
+	t.mu.Unlock()
+
+	if exists || !connected {
+		return sub, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.opts.ConnectTimeout)
+	defer cancel()
+	if err := t.backend.subscribe(ctx, topic, qos, broker.id); err != nil {
+		t.removeSubscription(sub)
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+
+	return sub, nil
+}
+
+// removeSubscription 删除本地订阅，返回 broker 订阅是否已没有本地订阅（已删除）
+func (t *Transport) removeSubscription(sub *subscription) bool {
+	sub.stop()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	broker := sub.broker
+	for i, other := range broker.subs {
+		if other == sub {
+			broker.subs = append(broker.subs[:i:i], broker.subs[i+1:]...)
+			break
+		}
+	}
+	if len(broker.subs) > 0 || t.brokerSubs[broker.topic] != broker {
+		return false
+	}
+	delete(t.brokerSubs, broker.topic)
+	delete(t.brokerSubIDs, broker.id)
+	return true
+}
+
+// unsubscribe 取消订阅，同一主题没有其他订阅时才向 broker 取消
+func (t *Transport) unsubscribe(sub *subscription) error {
+	if !t.removeSubscription(sub) || !t.IsConnected() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.opts.ConnectTimeout)
+	defer cancel()
+	return t.backend.unsubscribe(ctx, sub.broker.topic)
+}
+
+// Request 发送请求并等待回复（使用 MQTT 5 Response Topic 和 Correlation Data）
+func (t *Transport) Request(ctx context.Context, msg *sdk.Message) (*sdk.Message, error) {
+	if t.opts.ProtocolVersion != ProtocolV5 {
+		return nil, ErrRequestNotSupported
+	}
+	if err := t.ensureInbox(); err != nil {
+		return nil, err
+	}
+
+	correlation := randomHex(16)
+	ch := make(chan *sdk.Message, 1)
+	t.pendingMu.Lock()
+	t.pending[correlation] = ch
+	t.pendingMu.Unlock()
+	defer func() {
+		t.pendingMu.Lock()
+		delete(t.pending, correlation)
+		t.pendingMu.Unlock()
+	}()
+
+	out := &outboundMessage{
+		topic:           subjectToTopic(msg.Subject),
+		qos:             t.qosFor(msg.Subject),
+		payload:         msg.Data,
+		responseTopic:   t.inbox,
+		correlationData: []byte(correlation),
+	}
+	t.applyHeader(out, msg.Header)
+	if err := t.publish(ctx, out); err != nil {
+		return nil, err
+	}
+
+	select {
+	case reply := <-ch:
+		return reply, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ensureInbox 订阅本客户端的响应主题
+func (t *Transport) ensureInbox() error {
+	t.inboxOnce.Do(func() {
+		_, t.inboxErr = t.subscribe(t.inbox, t.inbox, false, 1, t.handleReply)
+	})
+	return t.inboxErr
+}
+
+// handleReply 将回复分发给等待中的请求
+func (t *Transport) handleReply(msg *sdk.Message) {
+	correlation, err := base64.StdEncoding.DecodeString(msg.Header.Get(correlationHeader))
+	if err != nil {
+		return
+	}
+
+	t.pendingMu.Lock()
+	ch, ok := t.pending[string(correlation)]
+	t.pendingMu.Unlock()
+	if ok {
+		msg.Header.Del(correlationHeader)
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+// Respond 回复请求（发布到请求的 Response Topic，并带回 Correlation Data）
+func (t *Transport) Respond(req *sdk.Message, resp *sdk.Message) error {
+	if req.Reply == "" {
+		return errors.New("message is not a request")
+	}
+
+	out := &outboundMessage{
+		topic:   req.Reply,
+		qos:     1,
+		payload: resp.Data,
+	}
+	if encoded := req.Header.Get(correlationHeader); encoded != "" {
+		correlation, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("invalid correlation data: %w", err)
+		}
+		out.correlationData = correlation
+	}
+	t.applyHeader(out, resp.Header)
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.opts.ConnectTimeout)
+	defer cancel()
+	return t.publish(ctx, out)
+}
+
+// dispatch 将收到的消息分发给匹配的订阅
+func (t *Transport) dispatch(in *inboundMessage) {
+	t.inMsgs.Add(1)
+	t.inBytes.Add(uint64(len(in.payload)))
+
+	msg := &sdk.Message{
+		Subject: topicToSubject(in.topic),
+		Reply:   in.responseTopic, // 保留 MQTT 响应主题原文，Respond 时直接使用
+		Data:    in.payload,
+	}
+	if len(in.correlationData) > 0 || in.contentType != "" || len(in.userProperties) > 0 {
+		msg.Header = make(sdk.Header)
+		if len(in.correlationData) > 0 {
+			msg.Header.Set(correlationHeader, base64.StdEncoding.EncodeToString(in.correlationData))
+		}
+		if in.contentType != "" {
+			msg.Header.Set(sdk.HeaderContentType, in.contentType)
+		}
+		for _, kv := range in.userProperties {
+			msg.Header.Add(kv[0], kv[1])
+		}
+	}
+
+	// 消息携带订阅标识符时只投递给对应的 broker 订阅（broker 合并重叠订阅时一条消息带多个标识符）；
+	// MQTT 3.1.1 或 broker 不支持订阅标识符时按主题匹配所有 broker 订阅
+	var targets []*subscription
+	t.mu.Lock()
+	var routed []*brokerSubscription
+	for _, id := range in.subscriptionIDs {
+		if broker, ok := t.brokerSubIDs[id]; ok && !slices.Contains(routed, broker) {
+			routed = append(routed, broker)
+			targets = broker.route(targets)
+		}
+	}
+	if len(routed) == 0 {
+		for _, broker := range t.brokerSubs {
+			if topicMatches(broker.filter, in.topic) {
+				targets = broker.route(targets)
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	// 每个订阅收到独立的副本，处理函数修改 Header 或 Data 不影响其他订阅
+	for i, sub := range targets {
+		if i < len(targets)-1 {
+			sub.deliver(copyMessage(msg))
+		} else {
+			sub.deliver(msg)
+		}
+	}
+}
+
+// copyMessage 复制消息，Header 和 Data 不与原消息共享
+func copyMessage(msg *sdk.Message) *sdk.Message {
+	out := &sdk.Message{
+		Subject: msg.Subject,
+		Reply:   msg.Reply,
+		Data:    append([]byte(nil), msg.Data...),
+	}
+	if msg.Header != nil {
+		out.Header = make(sdk.Header, len(msg.Header))
+		for k, v := range msg.Header {
+			out.Header[k] = append([]string(nil), v...)
+		}
+	}
+	return out
+}
+
+// onConnect 连接（或重连）成功：重新订阅并触发重连回调
+func (t *Transport) onConnect() {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return
+	}
+	reconnected := t.everConnected
+	t.connected = true
+	if !t.everConnected {
+		t.everConnected = true
+		close(t.firstConnect)
+	}
+	brokers := make([]*brokerSubscription, 0, len(t.brokerSubs))
+	for _, broker := range t.brokerSubs {
+		brokers = append(brokers, broker)
+	}
+	handler := t.reconnectHandler
+	t.mu.Unlock()
+
+	if reconnected {
+		t.reconnects.Add(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.opts.ConnectTimeout)
+	defer cancel()
+	for _, broker := range brokers {
+		if err := t.backend.subscribe(ctx, broker.topic, broker.qos, broker.id); err != nil {
+			t.opts.Logger.Errorf("MQTT resubscribe to %s failed: %v", broker.topic, err)
+		}
+	}
+
+	t.notifyState(sdk.ConnStateConnected, nil)
+	if reconnected && handler != nil {
+		go handler()
+	}
+}
+
+// onDisconnect 连接断开
+func (t *Transport) onDisconnect(err error) {
+	t.mu.Lock()
+	wasConnected := t.connected
+	t.connected = false
+	closed := t.closed
+	t.mu.Unlock()
+
+	if wasConnected && !closed {
+		// 客户端会自动重连
+		t.notifyState(sdk.ConnStateDisconnected, err)
+		t.notifyState(sdk.ConnStateReconnecting, nil)
+	}
+}
+
+// IsConnected 检查连接状态
+func (t *Transport) IsConnected() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.connected
+}
+
+// SetReconnectHandler 设置重连成功后的回调
+func (t *Transport) SetReconnectHandler(handler func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reconnectHandler = handler
+}
+
+// SetConnStateHandler 设置连接状态变化回调
+func (t *Transport) SetConnStateHandler(handler func(state sdk.ConnState, err error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stateHandler = handler
+}
+
+// notifyState 通知连接状态变化
+func (t *Transport) notifyState(state sdk.ConnState, err error) {
+	t.mu.RLock()
+	handler := t.stateHandler
+	t.mu.RUnlock()
+	if handler != nil {
+		handler(state, err)
+	}
+}
+
+// ConnState 当前连接状态
+func (t *Transport) ConnState() sdk.ConnState {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	switch {
+	case t.closed:
+		return sdk.ConnStateClosed
+	case t.connected:
+		return sdk.ConnStateConnected
+	case t.everConnected:
+		return sdk.ConnStateReconnecting
+	default:
+		return sdk.ConnStateDisconnected
+	}
+}
+
+// Stats 连接统计
+func (t *Transport) Stats() sdk.ConnStats {
+	return sdk.ConnStats{
+		InMsgs:     t.inMsgs.Load(),
+		OutMsgs:    t.outMsgs.Load(),
+		InBytes:    t.inBytes.Load(),
+		OutBytes:   t.outBytes.Load(),
+		Reconnects: t.reconnects.Load(),
+	}
+}
+
+// Close 断开连接
+func (t *Transport) Close() {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return
+	}
+	t.closed = true
+	t.connected = false
+	for _, broker := range t.brokerSubs {
+		for _, sub := range broker.subs {
+			sub.stop()
+		}
+	}
+	t.mu.Unlock()
+
+	t.backend.close()
+	t.notifyState(sdk.ConnStateClosed, nil)
+}
+
+// randomHex 生成随机十六进制字符串
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
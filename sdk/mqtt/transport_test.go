@@ -0,0 +1,162 @@
+package mqtt
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/punk-one/edge-app-sdk/sdk"
+	"github.com/sirupsen/logrus"
+)
+
+// nopBackend 不连接 broker 的 backend
+type nopBackend struct{}
+
+func (nopBackend) connect(context.Context) error                      { return nil }
+func (nopBackend) publish(context.Context, *outboundMessage) error    { return nil }
+func (nopBackend) subscribe(context.Context, string, byte, int) error { return nil }
+func (nopBackend) unsubscribe(context.Context, string) error          { return nil }
+func (nopBackend) close()                                             {}
+
+// newTestTransport 创建使用 nopBackend 的 Transport
+func newTestTransport(t *testing.T) *Transport {
+	tr := &Transport{
+		opts:         Options{ProtocolVersion: ProtocolV5, ConnectTimeout: time.Second, Logger: logrus.StandardLogger()},
+		backend:      nopBackend{},
+		connected:    true,
+		pending:      make(map[string]chan *sdk.Message),
+		brokerSubs:   make(map[string]*brokerSubscription),
+		brokerSubIDs: make(map[int]*brokerSubscription),
+		firstConnect: make(chan struct{}),
+	}
+	t.Cleanup(tr.Close)
+	return tr
+}
+
+// recorder 记录订阅收到的消息
+type recorder struct {
+	mu   sync.Mutex
+	msgs []*sdk.Message
+}
+
+func (r *recorder) handle(msg *sdk.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, msg)
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.msgs)
+}
+
+// waitCount 等待收到 n 条消息，再等待一小段时间确认没有多余的消息
+func (r *recorder) waitCount(t *testing.T, name string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for r.count() < n && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := r.count(); got != n {
+		t.Errorf("%s received %d messages, want %d", name, got, n)
+	}
+}
+
+func TestDispatchSubscriptionIDs(t *testing.T) {
+	tr := newTestTransport(t)
+
+	var plain, wild, queue recorder
+	for _, sub := range []struct {
+		subject string
+		queue   string
+		r       *recorder
+	}{
+		{"c.d", "", &plain},
+		{"c.>", "", &wild},
+		{"c.d", "g", &queue},
+	} {
+		var err error
+		if sub.queue == "" {
+			_, err = tr.Subscribe(sub.subject, sub.r.handle)
+		} else {
+			_, err = tr.QueueSubscribe(sub.subject, sub.queue, sub.r.handle)
+		}
+		if err != nil {
+			t.Fatalf("subscribe %s: %v", sub.subject, err)
+		}
+	}
+	ids := map[string]int{}
+	for topic, broker := range tr.brokerSubs {
+		ids[topic] = broker.id
+	}
+
+	// broker 合并重叠订阅：一份副本带两个订阅标识符（重复的标识符只投递一次）
+	tr.dispatch(&inboundMessage{topic: "c/d", payload: []byte("1"), subscriptionIDs: []int{ids["c/d"], ids["c/#"], ids["c/d"]}})
+	// 共享订阅的副本
+	tr.dispatch(&inboundMessage{topic: "c/d", payload: []byte("2"), subscriptionIDs: []int{ids["$share/g/c/d"]}})
+	// 没有已知的订阅标识符时按主题匹配
+	tr.dispatch(&inboundMessage{topic: "c/e", payload: []byte("3"), subscriptionIDs: []int{999}})
+
+	plain.waitCount(t, "plain", 1)
+	wild.waitCount(t, "wild", 2)
+	queue.waitCount(t, "queue", 1)
+}
+
+func TestDispatchCopiesMessage(t *testing.T) {
+	tr := newTestTransport(t)
+
+	var mu sync.Mutex
+	var got []*sdk.Message
+	handler := func(msg *sdk.Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		msg.Header.Add("modified", "yes")
+		msg.Data[0] = 'X'
+		got = append(got, msg)
+	}
+	for _, subject := range []string{"c.d", "c.*", "c.>"} {
+		if _, err := tr.Subscribe(subject, handler); err != nil {
+			t.Fatalf("subscribe %s: %v", subject, err)
+		}
+	}
+
+	tr.dispatch(&inboundMessage{
+		topic:          "c/d",
+		payload:        []byte("data"),
+		contentType:    "text/plain",
+		userProperties: [][2]string{{"k", "v"}},
+	})
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(got)
+		mu.Unlock()
+		if n == 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 3 {
+		t.Fatalf("received %d messages, want 3", len(got))
+	}
+	for i, msg := range got {
+		if vals := msg.Header["modified"]; len(vals) != 1 {
+			t.Errorf("message %d modified header = %v, want one value (header shared between subscriptions)", i, vals)
+		}
+		if msg.Header.Get("k") != "v" || msg.Header.Get(sdk.HeaderContentType) != "text/plain" {
+			t.Errorf("message %d header = %v", i, msg.Header)
+		}
+		for j := i + 1; j < len(got); j++ {
+			if &msg.Data[0] == &got[j].Data[0] {
+				t.Errorf("messages %d and %d share Data", i, j)
+			}
+		}
+	}
+}
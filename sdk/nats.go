@@ -1,81 +1,209 @@
 package sdk
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"time"
+	"sync"
 
 	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
 )
 
-// NATSClient NATS 客户端封装
+// NATSClient 基于 NATS 的 Transport 实现（默认传输层）
 type NATSClient struct {
-	conn *nats.Conn
+	conn             *nats.Conn
+	mu               sync.RWMutex
+	reconnectHandler func()
+	stateHandler     func(state ConnState, err error)
+	credsWatch       *configWatcher
+	logger           *logrus.Logger
 }
 
 // NewNATSClient 创建 NATS 客户端
 func NewNATSClient(url string) (*NATSClient, error) {
-	conn, err := nats.Connect(url,
-		nats.ReconnectWait(time.Second),
-		nats.MaxReconnects(-1),
-		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
-			if err != nil {
-				fmt.Printf("NATS disconnected: %v\n", err)
+	return NewNATSClientWithOptions(url, NATSOptions{})
+}
+
+// NewNATSClientWithOptions 使用 TLS、认证、集群和重连等连接选项创建 NATS 客户端
+// url 可以为空（只使用 opts.Servers）
+func NewNATSClientWithOptions(url string, opts NATSOptions) (*NATSClient, error) {
+	nc := &NATSClient{logger: opts.Logger}
+	if nc.logger == nil {
+		nc.logger = logrus.StandardLogger()
+	}
+
+	security, err := natsSecurityOptions(opts, nc.logger)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS options: %w", err)
+	}
+	options := append([]nats.Option{
+		nats.DisconnectErrHandler(func(conn *nats.Conn, err error) {
+			if conn.IsClosed() {
+				// 主动关闭，由 ClosedHandler 上报
+				return
 			}
+			nc.notifyState(ConnStateDisconnected, err)
+			if conn.IsReconnecting() {
+				nc.notifyState(ConnStateReconnecting, nil)
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			nc.logger.Debugf("NATS reconnected to %v", conn.ConnectedUrl())
+			nc.notifyState(ConnStateConnected, nil)
+			nc.mu.RLock()
+			handler := nc.reconnectHandler
+			nc.mu.RUnlock()
+			if handler != nil {
+				go handler()
+			}
+		}),
+		nats.ConnectHandler(func(conn *nats.Conn) {
+			// 以断开状态启动（RetryOnFailedConnect）后首次连接成功
+			nc.notifyState(ConnStateConnected, nil)
+		}),
+		nats.ClosedHandler(func(conn *nats.Conn) {
+			nc.notifyState(ConnStateClosed, conn.LastError())
 		}),
-		nats.ReconnectHandler(func(nc *nats.Conn) {
-			fmt.Printf("NATS reconnected to %v\n", nc.ConnectedUrl())
+		nats.ErrorHandler(func(conn *nats.Conn, sub *nats.Subscription, err error) {
+			if sub != nil {
+				nc.logger.Errorf("NATS error on %s: %v", sub.Subject, err)
+			} else {
+				nc.logger.Errorf("NATS error: %v", err)
+			}
 		}),
-	)
+	}, security...)
+	options = append(options, natsConnectionOptions(opts)...)
+
+	servers := natsServers(url, opts.Servers)
+	if servers == "" {
+		return nil, errors.New("no NATS server configured")
+	}
+	conn, err := nats.Connect(servers, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
 	}
+	nc.conn = conn
+	if !conn.IsConnected() {
+		nc.logger.Warnf("NATS server %s unavailable, retrying connection in background", servers)
+	}
+
+	if files := opts.credentialFiles(); opts.WatchFiles && len(files) > 0 {
+		if err := nc.watchCredentials(files); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to watch NATS credentials: %w", err)
+		}
+	}
 
-	return &NATSClient{conn: conn}, nil
+	return nc, nil
 }
 
-// Publish 发布消息
-func (nc *NATSClient) Publish(subject string, data interface{}) error {
-	payload, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal data: %w", err)
+// SetReconnectHandler 设置重连成功后的回调
+func (nc *NATSClient) SetReconnectHandler(handler func()) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	nc.reconnectHandler = handler
+}
+
+// SetConnStateHandler 设置连接状态变化回调
+func (nc *NATSClient) SetConnStateHandler(handler func(state ConnState, err error)) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	nc.stateHandler = handler
+}
+
+// notifyState 通知连接状态变化
+func (nc *NATSClient) notifyState(state ConnState, err error) {
+	nc.mu.RLock()
+	handler := nc.stateHandler
+	nc.mu.RUnlock()
+	if handler != nil {
+		handler(state, err)
+	}
+}
+
+// ConnState 当前连接状态
+func (nc *NATSClient) ConnState() ConnState {
+	if nc.conn == nil {
+		return ConnStateDisconnected
+	}
+	switch nc.conn.Status() {
+	case nats.CONNECTED, nats.DRAINING_SUBS, nats.DRAINING_PUBS:
+		return ConnStateConnected
+	case nats.RECONNECTING:
+		return ConnStateReconnecting
+	case nats.CLOSED:
+		return ConnStateClosed
+	default:
+		return ConnStateDisconnected
 	}
+}
 
-	return nc.conn.Publish(subject, payload)
+// Stats 连接统计
+func (nc *NATSClient) Stats() ConnStats {
+	if nc.conn == nil {
+		return ConnStats{}
+	}
+	stats := nc.conn.Stats()
+	return ConnStats{
+		InMsgs:     stats.InMsgs,
+		OutMsgs:    stats.OutMsgs,
+		InBytes:    stats.InBytes,
+		OutBytes:   stats.OutBytes,
+		Reconnects: stats.Reconnects,
+	}
+}
+
+// Publish 发布消息
+func (nc *NATSClient) Publish(msg *Message) error {
+	return nc.conn.PublishMsg(toNATSMsg(msg))
 }
 
 // Subscribe 订阅主题
-func (nc *NATSClient) Subscribe(subject string, handler func(*nats.Msg)) (*nats.Subscription, error) {
-	return nc.conn.Subscribe(subject, handler)
+func (nc *NATSClient) Subscribe(subject string, handler MessageHandler) (Subscription, error) {
+	return nc.conn.Subscribe(subject, func(msg *nats.Msg) {
+		handler(fromNATSMsg(msg))
+	})
 }
 
 // QueueSubscribe 队列订阅
-func (nc *NATSClient) QueueSubscribe(subject, queue string, handler func(*nats.Msg)) (*nats.Subscription, error) {
-	return nc.conn.QueueSubscribe(subject, queue, handler)
+func (nc *NATSClient) QueueSubscribe(subject, queue string, handler MessageHandler) (Subscription, error) {
+	return nc.conn.QueueSubscribe(subject, queue, func(msg *nats.Msg) {
+		handler(fromNATSMsg(msg))
+	})
 }
 
 // Request 发送请求（RPC）
-func (nc *NATSClient) Request(subject string, data interface{}, timeout time.Duration) (*nats.Msg, error) {
-	payload, err := json.Marshal(data)
+func (nc *NATSClient) Request(ctx context.Context, msg *Message) (*Message, error) {
+	reply, err := nc.conn.RequestMsgWithContext(ctx, toNATSMsg(msg))
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data: %w", err)
+		return nil, err
 	}
 
-	return nc.conn.Request(subject, payload, timeout)
+	return fromNATSMsg(reply), nil
 }
 
 // Respond 响应请求（RPC 回复）
-func (nc *NATSClient) Respond(reply string, data interface{}) error {
-	payload, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal data: %w", err)
+func (nc *NATSClient) Respond(req *Message, resp *Message) error {
+	if req.Reply == "" {
+		return errors.New("message is not a request")
 	}
 
-	return nc.conn.Publish(reply, payload)
+	reply := *resp
+	reply.Subject = req.Reply
+	return nc.conn.PublishMsg(toNATSMsg(&reply))
+}
+
+// Conn 返回底层 NATS 连接
+func (nc *NATSClient) Conn() *nats.Conn {
+	return nc.conn
 }
 
 // Close 关闭连接
 func (nc *NATSClient) Close() {
+	if nc.credsWatch != nil {
+		nc.credsWatch.close()
+	}
 	if nc.conn != nil {
 		nc.conn.Close()
 	}
@@ -86,3 +214,26 @@ func (nc *NATSClient) IsConnected() bool {
 	return nc.conn != nil && nc.conn.IsConnected()
 }
 
+// toNATSMsg 转换为 NATS 消息
+func toNATSMsg(msg *Message) *nats.Msg {
+	m := nats.NewMsg(msg.Subject)
+	m.Reply = msg.Reply
+	m.Data = msg.Data
+	for k, v := range msg.Header {
+		m.Header[k] = v
+	}
+	return m
+}
+
+// fromNATSMsg 从 NATS 消息转换
+func fromNATSMsg(msg *nats.Msg) *Message {
+	m := &Message{
+		Subject: msg.Subject,
+		Reply:   msg.Reply,
+		Data:    msg.Data,
+	}
+	if len(msg.Header) > 0 {
+		m.Header = Header(msg.Header)
+	}
+	return m
+}
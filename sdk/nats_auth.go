@@ -0,0 +1,279 @@
+package sdk
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	"github.com/sirupsen/logrus"
+)
+
+// NATSTLSOptions NATS TLS 配置，文件在每次（重）连接时重新读取
+type NATSTLSOptions struct {
+	CAFile             string // CA 证书文件（PEM），为空时使用系统根证书
+	CertFile           string // 客户端证书文件（PEM，mTLS）
+	KeyFile            string // 客户端私钥文件（PEM，mTLS）
+	ServerName         string // 校验服务端证书使用的主机名，默认取服务地址
+	InsecureSkipVerify bool   // 跳过服务端证书校验（仅用于测试）
+}
+
+// NATSAuthOptions NATS 认证配置，同时只能使用一种认证方式
+// *File 字段对应的文件在每次（重）连接时重新读取，支持凭据轮换
+type NATSAuthOptions struct {
+	User         string // 用户名
+	Password     string // 密码
+	PasswordFile string // 密码文件（与 Password 二选一）
+	Token        string // Token
+	TokenFile    string // Token 文件（与 Token 二选一）
+	NKeySeedFile string // NKey 种子文件
+	CredsFile    string // JWT 凭据文件（.creds）
+}
+
+// validate 检查认证方式是否冲突
+func (a NATSAuthOptions) validate() error {
+	var methods []string
+	if a.User != "" || a.Password != "" || a.PasswordFile != "" {
+		methods = append(methods, "user/password")
+	}
+	if a.Token != "" || a.TokenFile != "" {
+		methods = append(methods, "token")
+	}
+	if a.NKeySeedFile != "" {
+		methods = append(methods, "nkey")
+	}
+	if a.CredsFile != "" {
+		methods = append(methods, "creds")
+	}
+	if len(methods) > 1 {
+		return fmt.Errorf("conflicting NATS auth methods: %s", strings.Join(methods, ", "))
+	}
+
+	if a.Password != "" && a.PasswordFile != "" {
+		return errors.New("both Password and PasswordFile are set")
+	}
+	if a.Token != "" && a.TokenFile != "" {
+		return errors.New("both Token and TokenFile are set")
+	}
+	if (a.Password != "" || a.PasswordFile != "") && a.User == "" {
+		return errors.New("password set without User")
+	}
+	return nil
+}
+
+// natsSecurityOptions 将 TLS 和认证配置转换为 nats.Option
+func natsSecurityOptions(opts NATSOptions, logger *logrus.Logger) ([]nats.Option, error) {
+	if err := opts.Auth.validate(); err != nil {
+		return nil, err
+	}
+
+	var options []nats.Option
+	if opts.TLS != nil {
+		options = append(options, natsTLSOptions(*opts.TLS)...)
+	}
+
+	auth := opts.Auth
+	switch {
+	case auth.PasswordFile != "":
+		options = append(options, nats.UserInfoHandler(func() (string, string) {
+			password, err := readSecretFile(auth.PasswordFile)
+			if err != nil {
+				logger.Errorf("Failed to read NATS password file: %v", err)
+			}
+			return auth.User, password
+		}))
+	case auth.User != "":
+		options = append(options, nats.UserInfo(auth.User, auth.Password))
+	case auth.TokenFile != "":
+		options = append(options, nats.TokenHandler(func() string {
+			token, err := readSecretFile(auth.TokenFile)
+			if err != nil {
+				logger.Errorf("Failed to read NATS token file: %v", err)
+			}
+			return token
+		}))
+	case auth.Token != "":
+		options = append(options, nats.Token(auth.Token))
+	case auth.NKeySeedFile != "":
+		option, err := natsNKeyOption(auth.NKeySeedFile)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+	case auth.CredsFile != "":
+		// UserCredentials 在每次连接时重新读取文件
+		options = append(options, nats.UserCredentials(auth.CredsFile))
+	}
+
+	return options, nil
+}
+
+// natsTLSOptions TLS 相关 nats.Option，证书和 CA 在每次握手前重新读取
+func natsTLSOptions(t NATSTLSOptions) []nats.Option {
+	options := []nats.Option{
+		nats.Secure(&tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			ServerName:         t.ServerName,
+			InsecureSkipVerify: t.InsecureSkipVerify,
+		}),
+	}
+
+	var certCB nats.TLSCertHandler
+	if t.CertFile != "" || t.KeyFile != "" {
+		certCB = func() (tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+			if err != nil {
+				return tls.Certificate{}, fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			return cert, nil
+		}
+	}
+
+	var rootCAsCB nats.RootCAsHandler
+	if t.CAFile != "" {
+		rootCAsCB = func() (*x509.CertPool, error) {
+			data, err := os.ReadFile(t.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("no certificates found in CA file %s", t.CAFile)
+			}
+			return pool, nil
+		}
+	}
+
+	if certCB != nil || rootCAsCB != nil {
+		options = append(options, nats.ClientTLSConfig(certCB, rootCAsCB))
+	}
+	return options
+}
+
+// natsNKeyOption NKey 认证，签名时重新读取种子文件
+// 公钥在连接建立时确定，更换为不同的密钥对需要重新创建客户端
+func natsNKeyOption(seedFile string) (nats.Option, error) {
+	kp, err := loadNKeySeed(seedFile)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := kp.PublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nkey public key: %w", err)
+	}
+	kp.Wipe()
+
+	return nats.Nkey(pub, func(nonce []byte) ([]byte, error) {
+		kp, err := loadNKeySeed(seedFile)
+		if err != nil {
+			return nil, err
+		}
+		defer kp.Wipe()
+		return kp.Sign(nonce)
+	}), nil
+}
+
+// loadNKeySeed 读取 NKey 种子文件
+func loadNKeySeed(seedFile string) (nkeys.KeyPair, error) {
+	data, err := os.ReadFile(seedFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nkey seed file: %w", err)
+	}
+	kp, err := nkeys.ParseDecoratedNKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse nkey seed: %w", err)
+	}
+	return kp, nil
+}
+
+// readSecretFile 读取凭据文件（去除首尾空白）
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// credentialFiles 需要监听的证书和凭据文件
+func (opts NATSOptions) credentialFiles() []string {
+	var files []string
+	if opts.TLS != nil {
+		files = append(files, opts.TLS.CAFile, opts.TLS.CertFile, opts.TLS.KeyFile)
+	}
+	a := opts.Auth
+	files = append(files, a.PasswordFile, a.TokenFile, a.NKeySeedFile, a.CredsFile)
+
+	var out []string
+	for _, f := range files {
+		if f != "" {
+			out = append(out, filepath.Clean(f))
+		}
+	}
+	return out
+}
+
+// watchCredentials 监听证书和凭据文件，变更后强制重连
+// 与配置文件一样监听所在目录，以便处理原子替换（rename）和 Kubernetes Secret 的符号链接切换
+func (nc *NATSClient) watchCredentials(files []string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	watched := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		watched[f] = true
+		dir := filepath.Dir(f)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	nc.credsWatch = &configWatcher{watcher: watcher}
+	go func(w *configWatcher) {
+		for {
+			select {
+			case event, ok := <-w.watcher.Events:
+				if !ok {
+					return
+				}
+				// Kubernetes Secret 通过替换 ..data 符号链接更新，目录内任意变更都可能对应凭据更新
+				if !watched[filepath.Clean(event.Name)] && !strings.HasPrefix(filepath.Base(event.Name), "..") {
+					continue
+				}
+				w.debounce(nc.reloadCredentials)
+			case err, ok := <-w.watcher.Errors:
+				if !ok {
+					return
+				}
+				nc.logger.Errorf("NATS credentials watcher error: %v", err)
+			}
+		}
+	}(nc.credsWatch)
+
+	return nil
+}
+
+// reloadCredentials 凭据文件变更后强制重连，使新证书和凭据生效
+func (nc *NATSClient) reloadCredentials() {
+	if nc.conn == nil || nc.conn.IsClosed() {
+		return
+	}
+	nc.logger.Info("NATS credentials changed, reconnecting")
+	if err := nc.conn.ForceReconnect(); err != nil {
+		nc.logger.Errorf("NATS reconnect after credentials change failed: %v", err)
+	}
+}
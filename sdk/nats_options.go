@@ -0,0 +1,129 @@
+package sdk
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+)
+
+// NATSOptions NATS 连接选项
+type NATSOptions struct {
+	TLS  *NATSTLSOptions // TLS 配置，为空时根据服务地址（tls://）决定是否使用 TLS
+	Auth NATSAuthOptions // 认证配置
+
+	// 集群
+	Servers     []string // 服务地址列表（集群故障切换），与 Options.NatsURL 合并
+	NoRandomize bool     // 按列表顺序选择服务地址，默认随机选择以分散负载
+
+	// 重连
+	ReconnectWait    time.Duration                    // 重连等待时间，默认 1 秒
+	ReconnectJitter  time.Duration                    // 重连等待的随机抖动上限，默认 100 毫秒（TLS 连接默认 1 秒）
+	MaxReconnects    int                              // 最大重连次数，0 表示无限（默认），小于 0 表示不重连
+	ReconnectBufSize int                              // 断线期间发布消息的缓冲区大小（字节），默认 8MB，小于 0 表示不缓冲（断线时发布直接失败）
+	ReconnectDelay   func(attempts int) time.Duration // 自定义重连延迟（attempts 为尝试完整个服务列表的轮数），设置后忽略 ReconnectWait 和 ReconnectJitter
+
+	// 心跳检测
+	PingInterval        time.Duration // 客户端 PING 间隔，默认 2 分钟
+	MaxPingsOutstanding int           // 未收到 PONG 的 PING 数达到该值时认为连接已断开，默认 2
+
+	// RetryOnFailedConnect 启动时 NATS 不可用不返回错误：以断开状态启动，在后台按指数退避加随机抖动重试连接
+	// （以 ReconnectWait 为起点，最大 30 秒；设置 ReconnectDelay 时使用自定义延迟）。
+	// 首次连接成功前发布的消息会被缓存，订阅在连接成功后生效
+	RetryOnFailedConnect bool
+
+	// Logger NATS 客户端日志，默认使用 logrus 标准 logger；由 Client 创建时使用 SDK 的 logger
+	Logger *logrus.Logger
+
+	// WatchFiles 监听证书和凭据文件，变更后立即重连使新文件生效
+	// 关闭时文件同样在每次（重）连接时重新读取，只是要等到下次重连才生效
+	WatchFiles bool
+}
+
+// 重连参数默认值
+const (
+	defaultNATSReconnectWait = time.Second
+	natsRetryMaxWait         = 30 * time.Second
+)
+
+// natsServers 合并服务地址，返回逗号分隔的地址列表
+func natsServers(url string, servers []string) string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, list := range append([]string{url}, servers...) {
+		for _, u := range strings.Split(list, ",") {
+			u = strings.TrimSpace(u)
+			if u == "" || seen[u] {
+				continue
+			}
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	return strings.Join(urls, ",")
+}
+
+// natsConnectionOptions 集群、重连和心跳检测相关 nats.Option
+func natsConnectionOptions(opts NATSOptions) []nats.Option {
+	reconnectWait := opts.ReconnectWait
+	if reconnectWait <= 0 {
+		reconnectWait = defaultNATSReconnectWait
+	}
+
+	options := []nats.Option{nats.ReconnectWait(reconnectWait)}
+
+	switch {
+	case opts.MaxReconnects == 0:
+		options = append(options, nats.MaxReconnects(-1))
+	case opts.MaxReconnects < 0:
+		options = append(options, nats.MaxReconnects(0))
+	default:
+		options = append(options, nats.MaxReconnects(opts.MaxReconnects))
+	}
+
+	if opts.NoRandomize {
+		options = append(options, nats.DontRandomize())
+	}
+	if opts.ReconnectJitter > 0 {
+		options = append(options, nats.ReconnectJitter(opts.ReconnectJitter, opts.ReconnectJitter))
+	}
+	if opts.ReconnectBufSize != 0 {
+		options = append(options, nats.ReconnectBufSize(opts.ReconnectBufSize))
+	}
+	if opts.PingInterval > 0 {
+		options = append(options, nats.PingInterval(opts.PingInterval))
+	}
+	if opts.MaxPingsOutstanding > 0 {
+		options = append(options, nats.MaxPingsOutstanding(opts.MaxPingsOutstanding))
+	}
+
+	switch {
+	case opts.ReconnectDelay != nil:
+		options = append(options, nats.CustomReconnectDelay(opts.ReconnectDelay))
+	case opts.RetryOnFailedConnect:
+		options = append(options, nats.CustomReconnectDelay(func(attempts int) time.Duration {
+			return natsRetryBackoff(reconnectWait, attempts)
+		}))
+	}
+	if opts.RetryOnFailedConnect {
+		options = append(options, nats.RetryOnFailedConnect(true))
+	}
+
+	return options
+}
+
+// natsRetryBackoff 重试连接的等待时间：从 minWait 开始指数增长，最大 natsRetryMaxWait，
+// 并加入 ±20% 的随机抖动，避免大量设备同时重连
+func natsRetryBackoff(minWait time.Duration, attempts int) time.Duration {
+	wait := minWait
+	for i := 1; i < attempts && wait < natsRetryMaxWait; i++ {
+		wait *= 2
+	}
+	if wait > natsRetryMaxWait {
+		wait = natsRetryMaxWait
+	}
+	jitter := time.Duration(rand.Int63n(int64(wait)*2/5+1)) - wait/5
+	return wait + jitter
+}
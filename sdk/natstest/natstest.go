@@ -0,0 +1,99 @@
+// Package natstest 提供集成测试使用的进程内 nats-server：
+// 在随机端口启动嵌入式 nats-server，创建连接到它的 Client，测试结束时自动关闭，
+// 并可通过 Shutdown/Restart/DisconnectClients 确定性地模拟断线和重连。
+//
+//	func TestReconnect(t *testing.T) {
+//		client, srv := natstest.New(t, sdk.Options{AppKey: "app.camera"})
+//
+//		srv.Shutdown()
+//		client.EmitEvent("buffered", nil) // 断线期间发布的消息由 NATS 客户端缓存
+//		srv.Restart()
+//		srv.WaitForClients(1)
+//	}
+package natstest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/punk-one/edge-app-sdk/sdk"
+)
+
+// DefaultTimeout 等待服务启动和客户端连接的默认超时时间
+const DefaultTimeout = 5 * time.Second
+
+// New 启动嵌入式 nats-server 并创建连接到它的 Client，测试结束时自动关闭
+// 未指定时 AppKey 默认为 "app.test"，ConfigDir 使用测试临时目录
+func New(t testing.TB, opts sdk.Options) (*sdk.Client, *Server) {
+	t.Helper()
+
+	srv := RunServer(t, nil)
+
+	if opts.AppKey == "" {
+		opts.AppKey = "app.test"
+	}
+	if opts.ConfigDir == "" {
+		opts.ConfigDir = t.TempDir()
+	}
+	if opts.Transport == nil {
+		opts.Transport = srv.Connect()
+	}
+
+	client, err := sdk.NewClient(opts)
+	if err != nil {
+		t.Fatalf("natstest: failed to create client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client, srv
+}
+
+// WaitConnected 等待 NATSClient 连接（或重连）成功，并确认服务端已处理其订阅
+func WaitConnected(t testing.TB, nc *sdk.NATSClient) {
+	t.Helper()
+
+	deadline := time.Now().Add(DefaultTimeout)
+	for !nc.IsConnected() {
+		if time.Now().After(deadline) {
+			t.Fatalf("natstest: timed out waiting for NATS connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := nc.Conn().FlushTimeout(DefaultTimeout); err != nil {
+		t.Fatalf("natstest: failed to flush NATS connection: %v", err)
+	}
+}
+
+// WaitDisconnected 等待 NATSClient 检测到连接断开
+func WaitDisconnected(t testing.TB, nc *sdk.NATSClient) {
+	t.Helper()
+
+	deadline := time.Now().Add(DefaultTimeout)
+	for nc.IsConnected() {
+		if time.Now().After(deadline) {
+			t.Fatalf("natstest: timed out waiting for NATS disconnection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// defaultServerOptions 默认服务端选项：仅监听本地随机端口，关闭日志和信号处理
+func defaultServerOptions(t testing.TB, opts *server.Options) *server.Options {
+	var o server.Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Host == "" {
+		o.Host = "127.0.0.1"
+	}
+	if o.Port == 0 {
+		o.Port = server.RANDOM_PORT
+	}
+	if o.JetStream && o.StoreDir == "" {
+		o.StoreDir = t.TempDir()
+	}
+	o.NoLog = true
+	o.NoSigs = true
+	return &o
+}
@@ -0,0 +1,147 @@
+package natstest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/punk-one/edge-app-sdk/sdk"
+	"github.com/punk-one/edge-app-sdk/sdk/natstest"
+)
+
+// README 中的断线重连示例：断线期间发布的事件在重连后送达
+func TestReadmeRecipe(t *testing.T) {
+	srv := natstest.RunServer(t, nil)
+	transport := srv.Connect()
+	client, err := sdk.NewClient(sdk.Options{
+		AppKey:    "app.camera",
+		ConfigDir: t.TempDir(),
+		Transport: transport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// 同一连接上的订阅在重连时先于缓存的消息恢复
+	events := make(chan string, 10)
+	if _, err := transport.Subscribe(sdk.NewTopicBuilder("app.camera").Events(), func(msg *sdk.Message) {
+		var event sdk.EventData
+		if err := sdk.DecodeMessage(msg, &event); err == nil {
+			events <- event.Event
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Shutdown()
+	natstest.WaitDisconnected(t, transport)
+	client.EmitEvent("buffered", nil)
+	srv.Restart()
+	natstest.WaitConnected(t, transport)
+
+	select {
+	case event := <-events:
+		if event != "buffered" {
+			t.Errorf("event = %q, want buffered", event)
+		}
+	case <-time.After(natstest.DefaultTimeout):
+		t.Fatal("buffered event not delivered after reconnect")
+	}
+	if got := transport.Stats().Reconnects; got != 1 {
+		t.Errorf("Reconnects = %d, want 1", got)
+	}
+}
+
+// 包文档中的用法：New 一步创建服务和 Client
+func TestNew(t *testing.T) {
+	client, srv := natstest.New(t, sdk.Options{AppKey: "app.camera"})
+	srv.WaitForClients(1)
+
+	srv.Shutdown()
+	client.EmitEvent("buffered", nil)
+	srv.Restart()
+	srv.WaitForClients(1)
+
+	deadline := time.Now().Add(natstest.DefaultTimeout)
+	for client.ConnState() != sdk.ConnStateConnected {
+		if time.Now().After(deadline) {
+			t.Fatalf("client state = %v after restart", client.ConnState())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDisconnectClients(t *testing.T) {
+	srv := natstest.RunServer(t, nil)
+	a, b := srv.Connect(), srv.Connect()
+	srv.WaitForClients(2)
+
+	srv.DisconnectClients()
+
+	deadline := time.Now().Add(natstest.DefaultTimeout)
+	for a.Stats().Reconnects == 0 || b.Stats().Reconnects == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("clients did not reconnect: %d, %d", a.Stats().Reconnects, b.Stats().Reconnects)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	natstest.WaitConnected(t, a)
+	natstest.WaitConnected(t, b)
+	if !srv.Server().Running() {
+		t.Error("server stopped by DisconnectClients")
+	}
+}
+
+func TestJetStream(t *testing.T) {
+	srv := natstest.RunServer(t, &server.Options{JetStream: true})
+	if !srv.Server().JetStreamEnabled() {
+		t.Fatal("JetStream not enabled")
+	}
+
+	storageDir := t.TempDir()
+	client, err := sdk.NewClient(sdk.Options{
+		AppKey:     "app.camera",
+		ConfigDir:  t.TempDir(),
+		StorageDir: storageDir,
+		KVBucket:   "camera",
+		Transport:  srv.Connect(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), natstest.DefaultTimeout)
+	defer cancel()
+	kv, err := client.KV(ctx)
+	if err != nil {
+		t.Fatalf("KV() error = %v", err)
+	}
+	if _, err := kv.Put(ctx, "fps", []byte("30")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	entry, err := kv.Get(ctx, "fps")
+	if err != nil || string(entry.Value) != "30" {
+		t.Fatalf("Get() = %+v, %v", entry, err)
+	}
+
+	// 使用 JetStream KV，而不是本地文件
+	if _, err := os.Stat(filepath.Join(storageDir, "kv", "camera.json")); !os.IsNotExist(err) {
+		t.Errorf("local KV file exists (err = %v), want JetStream backend", err)
+	}
+	js, err := srv.Connect().Conn().JetStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bucket, err := js.KeyValue("camera")
+	if err != nil {
+		t.Fatalf("JetStream bucket not created: %v", err)
+	}
+	if value, err := bucket.Get("fps"); err != nil || string(value.Value()) != "30" {
+		t.Errorf("JetStream bucket value = %v, %v", value, err)
+	}
+}
@@ -0,0 +1,133 @@
+package natstest
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/punk-one/edge-app-sdk/sdk"
+)
+
+// Server 嵌入式 nats-server
+type Server struct {
+	t    testing.TB
+	opts *server.Options
+
+	mu  sync.Mutex
+	srv *server.Server
+}
+
+// RunServer 启动嵌入式 nats-server，测试结束时自动关闭
+// opts 为 nil 时使用默认选项；未指定端口时使用随机端口，开启 JetStream 且未指定 StoreDir 时使用测试临时目录
+func RunServer(t testing.TB, opts *server.Options) *Server {
+	t.Helper()
+
+	s := &Server{t: t, opts: defaultServerOptions(t, opts)}
+	s.start()
+	t.Cleanup(s.Shutdown)
+
+	// 固定实际端口，Restart 后客户端可以重连到同一地址
+	s.opts.Port = s.srv.Addr().(*net.TCPAddr).Port
+
+	return s
+}
+
+// start 创建并启动服务
+func (s *Server) start() {
+	s.t.Helper()
+
+	srv, err := server.NewServer(s.opts)
+	if err != nil {
+		s.t.Fatalf("natstest: failed to create nats-server: %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(DefaultTimeout) {
+		srv.Shutdown()
+		s.t.Fatalf("natstest: nats-server not ready for connections")
+	}
+
+	s.mu.Lock()
+	s.srv = srv
+	s.mu.Unlock()
+}
+
+// URL 客户端连接地址
+func (s *Server) URL() string {
+	return s.Server().ClientURL()
+}
+
+// Server 返回底层 nats-server（Restart 后为新实例）
+func (s *Server) Server() *server.Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.srv
+}
+
+// Connect 创建连接到该服务的 NATSClient，测试结束时自动关闭
+func (s *Server) Connect() *sdk.NATSClient {
+	s.t.Helper()
+	return s.ConnectWithOptions(sdk.NATSOptions{})
+}
+
+// ConnectWithOptions 使用 TLS、认证等连接选项创建连接到该服务的 NATSClient，测试结束时自动关闭
+func (s *Server) ConnectWithOptions(opts sdk.NATSOptions) *sdk.NATSClient {
+	s.t.Helper()
+
+	nc, err := sdk.NewNATSClientWithOptions(s.URL(), opts)
+	if err != nil {
+		s.t.Fatalf("natstest: failed to connect: %v", err)
+	}
+	s.t.Cleanup(nc.Close)
+	return nc
+}
+
+// Shutdown 关闭服务，已连接的客户端进入重连状态
+func (s *Server) Shutdown() {
+	s.mu.Lock()
+	srv := s.srv
+	s.mu.Unlock()
+
+	if srv != nil && srv.Running() {
+		srv.Shutdown()
+		srv.WaitForShutdown()
+	}
+}
+
+// Restart 在同一端口重新启动服务（未关闭时先关闭），客户端会自动重连
+func (s *Server) Restart() {
+	s.t.Helper()
+
+	s.Shutdown()
+	s.start()
+}
+
+// DisconnectClients 断开所有客户端连接（服务保持运行），模拟网络闪断
+func (s *Server) DisconnectClients() {
+	s.t.Helper()
+
+	srv := s.Server()
+	connz, err := srv.Connz(&server.ConnzOptions{Limit: srv.NumClients() + 1})
+	if err != nil {
+		s.t.Fatalf("natstest: failed to list connections: %v", err)
+	}
+	for _, conn := range connz.Conns {
+		if err := srv.DisconnectClientByID(conn.Cid); err != nil {
+			s.t.Fatalf("natstest: failed to disconnect client %d: %v", conn.Cid, err)
+		}
+	}
+}
+
+// WaitForClients 等待至少 n 个客户端连接到服务
+func (s *Server) WaitForClients(n int) {
+	s.t.Helper()
+
+	deadline := time.Now().Add(DefaultTimeout)
+	for s.Server().NumClients() < n {
+		if time.Now().After(deadline) {
+			s.t.Fatalf("natstest: timed out waiting for %d clients", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
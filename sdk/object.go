@@ -0,0 +1,487 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrObjectNotFound 对象不存在、已删除或已过期
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrDigestMismatch 读取的对象数据与 SHA-256 摘要不一致（数据损坏，或引用的对象已被覆盖）
+	ErrDigestMismatch = errors.New("object digest mismatch")
+)
+
+// DefaultObjectChunkSize 对象默认的分块大小
+const DefaultObjectChunkSize = 128 * 1024
+
+// 对象元数据中 SDK 使用的消息头
+const (
+	objectHeaderExpires = "Edge-Expires" // 过期时间（Unix 秒）
+)
+
+// ObjectPutOptions 上传对象的选项
+type ObjectPutOptions struct {
+	ContentType string            // 内容类型，如 "image/jpeg"、"application/gzip"
+	Description string            // 描述
+	Metadata    map[string]string // 自定义元数据
+	TTL         time.Duration     // 过期时间，默认 Options.ObjectTTL；小于 0 表示不过期。未设置 Options.ObjectTTL 时，过期对象只在本次运行调用过 Objects 后才在后台删除
+}
+
+// ObjectInfo 对象信息
+type ObjectInfo struct {
+	Bucket      string            // bucket 名称
+	Name        string            // 对象名称
+	Description string            // 描述
+	ContentType string            // 内容类型
+	Metadata    map[string]string // 自定义元数据
+	Size        int64             // 大小（字节）
+	Chunks      int               // 分块数
+	Digest      string            // SHA-256 摘要，格式为 "SHA-256=<base64url>"，与 JetStream 对象存储一致
+	Modified    time.Time         // 上传时间
+	Expires     time.Time         // 过期时间，零值表示不过期
+
+	backend StorageBackend
+	path    string // 本地存储时数据文件的路径
+}
+
+// expired 对象是否已过期
+func (i ObjectInfo) expired(now time.Time) bool {
+	return !i.Expires.IsZero() && !now.Before(i.Expires)
+}
+
+// Ref 返回对象引用，可以放入 CommandResult.Data 等消息中，由接收方通过 Client.OpenObject 读取
+func (i ObjectInfo) Ref() ObjectRef {
+	ref := ObjectRef{
+		Backend:     i.backend,
+		Bucket:      i.Bucket,
+		Name:        i.Name,
+		Size:        i.Size,
+		Digest:      i.Digest,
+		ContentType: i.ContentType,
+		Path:        i.path,
+	}
+	if !i.Expires.IsZero() {
+		ref.Expires = i.Expires.Unix()
+	}
+	return ref
+}
+
+// ObjectRef 对象引用
+type ObjectRef struct {
+	Backend     StorageBackend `json:"backend"`
+	Bucket      string         `json:"bucket"`
+	Name        string         `json:"name"`
+	Size        int64          `json:"size"`
+	Digest      string         `json:"digest"`
+	ContentType string         `json:"content_type,omitempty"`
+	Expires     int64          `json:"expires,omitempty"` // 过期时间（Unix 秒）
+	Path        string         `json:"path,omitempty"`    // 本地存储时数据文件的路径（权限 0600），同一设备上以相同用户运行的进程可以直接读取；OpenObject 不使用该字段
+}
+
+// ObjectStore App 的对象存储，用于快照、日志包等较大的数据；对象分块存储，读取时校验 SHA-256 摘要
+type ObjectStore interface {
+	// Bucket bucket 名称
+	Bucket() string
+	// Backend 存储后端
+	Backend() StorageBackend
+	// Put 上传对象，同名对象被覆盖
+	Put(ctx context.Context, name string, r io.Reader, opts ...ObjectPutOptions) (ObjectInfo, error)
+	// Get 读取对象，调用方需关闭返回的 Reader；读到末尾时校验摘要，不一致时返回 ErrDigestMismatch
+	// JetStream 后端在读取期间需要保持 ctx 有效
+	Get(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error)
+	// Info 返回对象信息，对象不存在或已过期时返回 ErrObjectNotFound
+	Info(ctx context.Context, name string) (ObjectInfo, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, name string) error
+	// List 返回未过期的对象，按名称排序
+	List(ctx context.Context) ([]ObjectInfo, error)
+}
+
+// objectBackend 对象存储后端，objectStore 在其上实现名称校验、默认选项和过期
+type objectBackend interface {
+	bucket() string
+	kind() StorageBackend
+	put(ctx context.Context, meta objectMeta, r io.Reader) (ObjectInfo, error)
+	get(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error)
+	info(ctx context.Context, name string) (ObjectInfo, error)
+	delete(ctx context.Context, name string) error
+	list(ctx context.Context) ([]ObjectInfo, error)
+}
+
+// objectMeta 上传对象的元数据
+type objectMeta struct {
+	Name        string
+	Description string
+	ContentType string
+	Metadata    map[string]string
+	Expires     time.Time
+	ChunkSize   int
+}
+
+// objectStore ObjectStore 的实现
+type objectStore struct {
+	backend   objectBackend
+	now       func() time.Time
+	ttl       time.Duration
+	chunkSize int
+}
+
+// validateObjectName 检查对象名称
+func validateObjectName(name string) error {
+	if name == "" {
+		return errors.New("object name is required")
+	}
+	return nil
+}
+
+func (s *objectStore) Bucket() string {
+	return s.backend.bucket()
+}
+
+func (s *objectStore) Backend() StorageBackend {
+	return s.backend.kind()
+}
+
+func (s *objectStore) Put(ctx context.Context, name string, r io.Reader, opts ...ObjectPutOptions) (ObjectInfo, error) {
+	if err := validateObjectName(name); err != nil {
+		return ObjectInfo{}, err
+	}
+	o := ObjectPutOptions{}
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.TTL == 0 {
+		o.TTL = s.ttl
+	}
+
+	meta := objectMeta{
+		Name:        name,
+		Description: o.Description,
+		ContentType: o.ContentType,
+		Metadata:    o.Metadata,
+		ChunkSize:   s.chunkSize,
+	}
+	if o.TTL > 0 {
+		// 过期时间精确到秒，与 ObjectRef.Expires 一致
+		meta.Expires = s.now().Add(o.TTL).Truncate(time.Second)
+	}
+	info, err := s.backend.put(ctx, meta, r)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to put object %s: %w", name, err)
+	}
+	return info, nil
+}
+
+func (s *objectStore) Get(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error) {
+	if err := validateObjectName(name); err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	r, info, err := s.backend.get(ctx, name)
+	if err != nil {
+		return nil, ObjectInfo{}, fmt.Errorf("failed to get object %s: %w", name, err)
+	}
+	if info.expired(s.now()) {
+		r.Close()
+		return nil, ObjectInfo{}, fmt.Errorf("%w: %s", ErrObjectNotFound, name)
+	}
+	return r, info, nil
+}
+
+func (s *objectStore) Info(ctx context.Context, name string) (ObjectInfo, error) {
+	if err := validateObjectName(name); err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err := s.backend.info(ctx, name)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to get object info %s: %w", name, err)
+	}
+	if info.expired(s.now()) {
+		return ObjectInfo{}, fmt.Errorf("%w: %s", ErrObjectNotFound, name)
+	}
+	return info, nil
+}
+
+func (s *objectStore) Delete(ctx context.Context, name string) error {
+	if err := validateObjectName(name); err != nil {
+		return err
+	}
+	if err := s.backend.delete(ctx, name); err != nil && !errors.Is(err, ErrObjectNotFound) {
+		return fmt.Errorf("failed to delete object %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *objectStore) List(ctx context.Context) ([]ObjectInfo, error) {
+	infos, err := s.backend.list(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+	now := s.now()
+	objects := make([]ObjectInfo, 0, len(infos))
+	for _, info := range infos {
+		if !info.expired(now) {
+			objects = append(objects, info)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	return objects, nil
+}
+
+// deleteExpired 删除已过期的对象，返回删除的数量
+func (s *objectStore) deleteExpired(ctx context.Context) (int, error) {
+	infos, err := s.backend.list(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list objects: %w", err)
+	}
+	now := s.now()
+	deleted := 0
+	for _, info := range infos {
+		if !info.expired(now) {
+			continue
+		}
+		if err := s.backend.delete(ctx, info.Name); err != nil && !errors.Is(err, ErrObjectNotFound) {
+			return deleted, fmt.Errorf("failed to delete object %s: %w", info.Name, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Objects 返回 App 的对象存储（bucket 为 Options.ObjectBucket），第一次调用时打开
+// 与 KV 相同，传输层支持 JetStream 且已连接时使用 JetStream 对象存储（bucket 不存在时创建），
+// 否则使用本地目录 <StorageDir>/objects/<bucket>；后端在打开时确定，之后不会切换
+func (c *Client) Objects(ctx context.Context) (ObjectStore, error) {
+	c.objectsMu.Lock()
+	defer c.objectsMu.Unlock()
+	if c.objects != nil {
+		return c.objects, nil
+	}
+
+	bucket := c.opts.ObjectBucket
+	var backend objectBackend
+	js, err := c.jetStream(ctx)
+	if err == nil {
+		backend, err = c.openJetStreamObjects(ctx, js, bucket, true)
+		if err != nil {
+			return nil, err
+		}
+		c.logger.Debugf("Opened JetStream object store %s", bucket)
+	} else {
+		c.logger.Infof("Using local object store for bucket %s: %v", bucket, err)
+		backend, err = c.openLocalObjects(bucket)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.objects = c.newObjectStore(backend)
+	c.startObjectExpiry()
+	return c.objects, nil
+}
+
+// newObjectStore 使用 Options 中的默认选项创建 objectStore
+func (c *Client) newObjectStore(backend objectBackend) *objectStore {
+	return &objectStore{
+		backend:   backend,
+		now:       c.now,
+		ttl:       c.opts.ObjectTTL,
+		chunkSize: c.opts.ObjectChunkSize,
+	}
+}
+
+// OpenObject 读取对象引用指向的对象（可以是其他 App 上传的），调用方需关闭返回的 Reader
+// 按引用中的 bucket 和名称读取：JetStream 对象从 nats-server 读取；本地对象从 <StorageDir>/objects/<bucket> 读取，
+// 只能在同一设备上读取，不使用引用中的 path（引用来自其他 App 的消息，不能用于读取任意文件）。
+// 对象在引用生成后被覆盖时返回 ErrDigestMismatch
+func (c *Client) OpenObject(ctx context.Context, ref ObjectRef) (io.ReadCloser, ObjectInfo, error) {
+	if ref.Expires > 0 && !c.now().Before(time.Unix(ref.Expires, 0)) {
+		return nil, ObjectInfo{}, fmt.Errorf("%w: %s (expired)", ErrObjectNotFound, ref.Name)
+	}
+
+	var (
+		r    io.ReadCloser
+		info ObjectInfo
+		err  error
+	)
+	switch ref.Backend {
+	case StorageLocal:
+		r, info, err = c.openLocalObject(ctx, ref)
+	case StorageJetStream:
+		r, info, err = c.openJetStreamObject(ctx, ref)
+	default:
+		return nil, ObjectInfo{}, fmt.Errorf("unsupported object backend: %q", ref.Backend)
+	}
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	if ref.Digest != "" && info.Digest != ref.Digest {
+		r.Close()
+		return nil, ObjectInfo{}, fmt.Errorf("%w: %s was replaced", ErrDigestMismatch, ref.Name)
+	}
+	return r, info, nil
+}
+
+// openJetStreamObject 按引用中的 bucket 读取 JetStream 对象
+func (c *Client) openJetStreamObject(ctx context.Context, ref ObjectRef) (io.ReadCloser, ObjectInfo, error) {
+	js, err := c.jetStream(ctx)
+	if err != nil {
+		return nil, ObjectInfo{}, fmt.Errorf("failed to open object %s: %w", ref.Name, err)
+	}
+	backend, err := c.openJetStreamObjects(ctx, js, ref.Bucket, false)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	return c.newObjectStore(backend).Get(ctx, ref.Name)
+}
+
+// openLocalObject 按引用中的 bucket 和名称读取本地对象
+func (c *Client) openLocalObject(ctx context.Context, ref ObjectRef) (io.ReadCloser, ObjectInfo, error) {
+	if err := validateBucketName(ref.Bucket); err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	return c.newObjectStore(c.localObjects(ref.Bucket)).Get(ctx, ref.Name)
+}
+
+// objectExpireInterval 后台清理过期对象的间隔，ObjectTTL 更短时使用 ObjectTTL
+const objectExpireInterval = time.Minute
+
+// startObjectExpiry 启动过期对象的定时清理（只启动一次），直到 Client 关闭
+// 定时器在调用方的 goroutine 中创建，不会与 FakeClock.WaitForTickers 等待的心跳定时器竞争
+func (c *Client) startObjectExpiry() {
+	c.objectsExpiry.Do(func() {
+		interval := objectExpireInterval
+		if ttl := c.opts.ObjectTTL; ttl > 0 && ttl < interval {
+			interval = ttl
+		}
+		ticker := c.clock.NewTicker(interval)
+
+		go func() {
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C():
+					if c.isRunning() {
+						c.expireObjects()
+					}
+				case <-c.heartbeatStop:
+					return
+				}
+			}
+		}()
+	})
+}
+
+// expireObjects 在后台删除对象存储中已过期的对象；上一次清理未结束时跳过
+// 对象存储还没有打开时，只有设置了 ObjectTTL 才清理（上次运行留下的对象），且不缓存打开的存储，
+// 以免在 JetStream 可用之前就确定使用本地后端
+func (c *Client) expireObjects() {
+	c.objectsMu.Lock()
+	store := c.objects
+	c.objectsMu.Unlock()
+	if store == nil && c.opts.ObjectTTL <= 0 {
+		return
+	}
+	if !c.objectsExpiring.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer c.objectsExpiring.Store(false)
+		ctx := context.Background()
+		if store == nil {
+			var err error
+			if store, err = c.openObjectsForExpiry(ctx); err != nil || store == nil {
+				if err != nil {
+					c.logger.Debugf("Failed to open object store for expiry: %v", err)
+				}
+				return
+			}
+		}
+		deleted, err := store.deleteExpired(ctx)
+		if err != nil {
+			c.logger.Debugf("Failed to delete expired objects: %v", err)
+		}
+		if deleted > 0 {
+			c.logger.Debugf("Deleted %d expired objects from %s", deleted, store.Bucket())
+		}
+	}()
+}
+
+// openObjectsForExpiry 打开已存在的对象存储用于清理，选择后端的规则与 Objects 相同；bucket 不存在时返回 nil
+func (c *Client) openObjectsForExpiry(ctx context.Context) (*objectStore, error) {
+	bucket := c.opts.ObjectBucket
+	if js, err := c.jetStream(ctx); err == nil {
+		backend, err := c.openJetStreamObjects(ctx, js, bucket, false)
+		if err != nil {
+			return nil, err
+		}
+		return c.newObjectStore(backend), nil
+	}
+
+	backend := c.localObjects(bucket)
+	if _, err := os.Stat(backend.dir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open object directory: %w", err)
+	}
+	return c.newObjectStore(backend), nil
+}
+
+// formatDigest 格式化 SHA-256 摘要
+func formatDigest(h hash.Hash) string {
+	return "SHA-256=" + base64.URLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// formatExpires 格式化过期时间（Unix 秒），零值表示不过期
+func formatExpires(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// parseExpires 解析过期时间
+func parseExpires(s string) time.Time {
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || sec <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// digestReader 读取时计算 SHA-256，读到末尾时与期望的摘要比较
+type digestReader struct {
+	r      io.ReadCloser
+	hash   hash.Hash
+	digest string
+}
+
+// newDigestReader 创建校验摘要的 Reader
+func newDigestReader(r io.ReadCloser, digest string) *digestReader {
+	return &digestReader{r: r, hash: sha256.New(), digest: digest}
+}
+
+func (d *digestReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	d.hash.Write(p[:n])
+	if err == io.EOF && formatDigest(d.hash) != d.digest {
+		return n, ErrDigestMismatch
+	}
+	return n, err
+}
+
+func (d *digestReader) Close() error {
+	return d.r.Close()
+}
@@ -0,0 +1,149 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// jetStreamObjects 基于 JetStream 对象存储的 objectBackend，分块和摘要由 JetStream 处理
+type jetStreamObjects struct {
+	name string
+	obs  jetstream.ObjectStore
+}
+
+// openJetStreamObjects 打开对象存储，create 为 true 时 bucket 不存在则创建
+func (c *Client) openJetStreamObjects(ctx context.Context, js jetstream.JetStream, bucket string, create bool) (*jetStreamObjects, error) {
+	obs, err := js.ObjectStore(ctx, bucket)
+	if create && errors.Is(err, jetstream.ErrBucketNotFound) {
+		obs, err = js.CreateObjectStore(ctx, jetstream.ObjectStoreConfig{
+			Bucket:      bucket,
+			Description: "edge app " + c.opts.AppKey,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object store %s: %w", bucket, err)
+	}
+	return &jetStreamObjects{name: bucket, obs: obs}, nil
+}
+
+func (s *jetStreamObjects) bucket() string {
+	return s.name
+}
+
+func (s *jetStreamObjects) kind() StorageBackend {
+	return StorageJetStream
+}
+
+func (s *jetStreamObjects) put(ctx context.Context, meta objectMeta, r io.Reader) (ObjectInfo, error) {
+	headers := nats.Header{}
+	if meta.ContentType != "" {
+		headers.Set(HeaderContentType, meta.ContentType)
+	}
+	if expires := formatExpires(meta.Expires); expires != "" {
+		headers.Set(objectHeaderExpires, expires)
+	}
+
+	info, err := s.obs.Put(ctx, jetstream.ObjectMeta{
+		Name:        meta.Name,
+		Description: meta.Description,
+		Headers:     headers,
+		Metadata:    meta.Metadata,
+		Opts:        &jetstream.ObjectMetaOptions{ChunkSize: uint32(meta.ChunkSize)},
+	}, r)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return fromJetStreamObject(info), nil
+}
+
+func (s *jetStreamObjects) get(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error) {
+	result, err := s.obs.Get(ctx, name)
+	if err != nil {
+		return nil, ObjectInfo{}, jetStreamObjectError(err)
+	}
+	info, err := result.Info()
+	if err != nil {
+		result.Close()
+		return nil, ObjectInfo{}, err
+	}
+	return &jetStreamObjectReader{result}, fromJetStreamObject(info), nil
+}
+
+func (s *jetStreamObjects) info(ctx context.Context, name string) (ObjectInfo, error) {
+	info, err := s.obs.GetInfo(ctx, name)
+	if err != nil {
+		return ObjectInfo{}, jetStreamObjectError(err)
+	}
+	return fromJetStreamObject(info), nil
+}
+
+func (s *jetStreamObjects) delete(ctx context.Context, name string) error {
+	return jetStreamObjectError(s.obs.Delete(ctx, name))
+}
+
+func (s *jetStreamObjects) list(ctx context.Context) ([]ObjectInfo, error) {
+	infos, err := s.obs.List(ctx)
+	if errors.Is(err, jetstream.ErrNoObjectsFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]ObjectInfo, 0, len(infos))
+	for _, info := range infos {
+		objects = append(objects, fromJetStreamObject(info))
+	}
+	return objects, nil
+}
+
+// jetStreamObjectError 将 JetStream 对象存储的错误转换为 SDK 的错误
+func jetStreamObjectError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, jetstream.ErrObjectNotFound):
+		return ErrObjectNotFound
+	case errors.Is(err, jetstream.ErrDigestMismatch):
+		return ErrDigestMismatch
+	default:
+		return err
+	}
+}
+
+// jetStreamObjectReader 将读取时的摘要错误转换为 ErrDigestMismatch
+type jetStreamObjectReader struct {
+	jetstream.ObjectResult
+}
+
+func (r *jetStreamObjectReader) Read(p []byte) (int, error) {
+	n, err := r.ObjectResult.Read(p)
+	if err != nil && err != io.EOF {
+		err = jetStreamObjectError(err)
+	}
+	return n, err
+}
+
+// fromJetStreamObject 转换 JetStream 对象信息
+func fromJetStreamObject(info *jetstream.ObjectInfo) ObjectInfo {
+	object := ObjectInfo{
+		Bucket:      info.Bucket,
+		Name:        info.Name,
+		Description: info.Description,
+		Metadata:    info.Metadata,
+		Size:        int64(info.Size),
+		Chunks:      int(info.Chunks),
+		Digest:      info.Digest,
+		Modified:    info.ModTime,
+		backend:     StorageJetStream,
+	}
+	if info.Headers != nil {
+		object.ContentType = info.Headers.Get(HeaderContentType)
+		object.Expires = parseExpires(info.Headers.Get(objectHeaderExpires))
+	}
+	return object
+}
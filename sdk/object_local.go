@@ -0,0 +1,282 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// localObjectFileMode 本地对象文件权限
+const localObjectFileMode = 0600
+
+// localObjects 基于本地目录的 objectBackend，JetStream 不可用时使用
+// 每个对象由元数据文件 <id>.json 和数据文件 <id>.<随机后缀>.data 组成（id 为名称的 SHA-256）；
+// 覆盖时先写入新的数据文件，再原子地替换元数据文件，正在读取旧数据的 Reader 不受影响
+type localObjects struct {
+	name string
+	dir  string
+	now  func() time.Time
+
+	mu sync.Mutex // 串行化元数据的替换、删除和数据文件的打开
+}
+
+// localObjectMeta 本地对象的元数据文件格式
+type localObjectMeta struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Size        int64             `json:"size"`
+	Chunks      int               `json:"chunks"`
+	Digest      string            `json:"digest"`
+	Modified    time.Time         `json:"modified"`
+	Expires     int64             `json:"expires,omitempty"`
+	File        string            `json:"file"` // 数据文件名
+}
+
+// openLocalObjects 打开（或创建）本地对象目录
+func (c *Client) openLocalObjects(bucket string) (*localObjects, error) {
+	s := c.localObjects(bucket)
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create object directory: %w", err)
+	}
+	return s, nil
+}
+
+// localObjects 本地对象目录 <StorageDir>/objects/<bucket>，不创建目录（只读取时使用）
+func (c *Client) localObjects(bucket string) *localObjects {
+	return &localObjects{name: bucket, dir: filepath.Join(c.opts.StorageDir, "objects", bucket), now: c.now}
+}
+
+func (s *localObjects) bucket() string {
+	return s.name
+}
+
+func (s *localObjects) kind() StorageBackend {
+	return StorageLocal
+}
+
+// objectID 对象名称对应的文件名前缀
+func objectID(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *localObjects) metaPath(name string) string {
+	return filepath.Join(s.dir, objectID(name)+".json")
+}
+
+func (s *localObjects) put(ctx context.Context, meta objectMeta, r io.Reader) (ObjectInfo, error) {
+	tmp, err := os.CreateTemp(s.dir, objectID(meta.Name)+".*.data")
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to create data file: %w", err)
+	}
+	dataPath := tmp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(dataPath)
+		}
+	}()
+
+	// 按块写入，同时计算摘要
+	hash := sha256.New()
+	buf := make([]byte, meta.ChunkSize)
+	var size int64
+	chunks := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return ObjectInfo{}, err
+		}
+		n, readErr := readChunk(r, buf)
+		if n > 0 {
+			if _, err := tmp.Write(buf[:n]); err != nil {
+				return ObjectInfo{}, fmt.Errorf("failed to write data file: %w", err)
+			}
+			hash.Write(buf[:n])
+			size += int64(n)
+			chunks++
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return ObjectInfo{}, fmt.Errorf("failed to read object data: %w", readErr)
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to sync data file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to close data file: %w", err)
+	}
+
+	m := localObjectMeta{
+		Name:        meta.Name,
+		Description: meta.Description,
+		ContentType: meta.ContentType,
+		Metadata:    meta.Metadata,
+		Size:        size,
+		Chunks:      chunks,
+		Digest:      formatDigest(hash),
+		Modified:    s.now().UTC(),
+		File:        filepath.Base(dataPath),
+	}
+	if !meta.Expires.IsZero() {
+		m.Expires = meta.Expires.Unix()
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to marshal object metadata: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, _ := s.readMeta(meta.Name)
+	if err := writeFileAtomic(s.metaPath(meta.Name), data, localObjectFileMode); err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to write object metadata: %w", err)
+	}
+	committed = true
+	if previous != nil {
+		os.Remove(s.dataPath(previous))
+	}
+	return s.toInfo(m), nil
+}
+
+// readChunk 读满一块，数据读完时返回已读的字节数和 io.EOF
+// 与 io.ReadFull 不同，数据源返回的 io.ErrUnexpectedEOF（如截断的 gzip 或 HTTP 响应）作为错误返回，不会当作正常结束
+func readChunk(r io.Reader, buf []byte) (int, error) {
+	n := 0
+	for n < len(buf) {
+		m, err := r.Read(buf[n:])
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (s *localObjects) get(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.readMeta(name)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	f, err := os.Open(s.dataPath(m))
+	if err != nil {
+		return nil, ObjectInfo{}, fmt.Errorf("failed to open data file: %w", err)
+	}
+	return newDigestReader(f, m.Digest), s.toInfo(*m), nil
+}
+
+func (s *localObjects) info(ctx context.Context, name string) (ObjectInfo, error) {
+	m, err := s.readMeta(name)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return s.toInfo(*m), nil
+}
+
+func (s *localObjects) delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.readMeta(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(s.metaPath(name)); err != nil {
+		return fmt.Errorf("failed to remove object metadata: %w", err)
+	}
+	if err := os.Remove(s.dataPath(m)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove data file: %w", err)
+	}
+	return nil
+}
+
+func (s *localObjects) list(ctx context.Context) ([]ObjectInfo, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object directory: %w", err)
+	}
+	var objects []ObjectInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue // 已被并发删除
+		}
+		var m localObjectMeta
+		if err := json.Unmarshal(data, &m); err != nil || !isLocalDataFile(m.File) {
+			continue
+		}
+		objects = append(objects, s.toInfo(m))
+	}
+	return objects, nil
+}
+
+// dataPath 数据文件路径，readMeta 已保证文件名不含路径分隔符，不会指向 bucket 目录之外
+func (s *localObjects) dataPath(m *localObjectMeta) string {
+	return filepath.Join(s.dir, m.File)
+}
+
+// readMeta 读取对象的元数据文件，不存在时返回 ErrObjectNotFound
+// 元数据与名称不符或数据文件名不是 bucket 目录中的文件时视为损坏
+func (s *localObjects) readMeta(name string) (*localObjectMeta, error) {
+	data, err := os.ReadFile(s.metaPath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object metadata: %w", err)
+	}
+	var m localObjectMeta
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse object metadata: %w", err)
+	}
+	if m.Name != name || !isLocalDataFile(m.File) {
+		return nil, fmt.Errorf("invalid object metadata for %s", name)
+	}
+	return &m, nil
+}
+
+// isLocalDataFile 数据文件名是否为 bucket 目录中的 .data 文件（不含路径分隔符和 ..）
+func isLocalDataFile(file string) bool {
+	return filepath.Base(file) == file && !strings.ContainsAny(file, `/\`) && strings.HasSuffix(file, ".data")
+}
+
+// toInfo 转换为 ObjectInfo
+func (s *localObjects) toInfo(m localObjectMeta) ObjectInfo {
+	info := ObjectInfo{
+		Bucket:      s.name,
+		Name:        m.Name,
+		Description: m.Description,
+		ContentType: m.ContentType,
+		Metadata:    m.Metadata,
+		Size:        m.Size,
+		Chunks:      m.Chunks,
+		Digest:      m.Digest,
+		Modified:    m.Modified,
+		backend:     StorageLocal,
+		path:        filepath.Join(s.dir, m.File),
+	}
+	if m.Expires > 0 {
+		info.Expires = time.Unix(m.Expires, 0)
+	}
+	return info
+}
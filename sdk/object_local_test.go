@@ -0,0 +1,77 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"testing/iotest"
+)
+
+func newTestLocalObjects(t *testing.T) (*objectStore, *localObjects) {
+	t.Helper()
+	c := &Client{opts: Options{StorageDir: t.TempDir(), ObjectChunkSize: 4}, clock: realClock{}}
+	backend, err := c.openLocalObjects("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.newObjectStore(backend), backend
+}
+
+func TestLocalObjectsPutChunks(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		chunks int
+	}{
+		{"empty", "", 0},
+		{"partial chunk", "abc", 1},
+		{"exact chunks", "abcdefgh", 2},
+		{"trailing chunk", "abcdefghi", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := newTestLocalObjects(t)
+			// OneByteReader 每次只返回一个字节，分块不应依赖单次 Read 的长度
+			info, err := store.Put(context.Background(), "obj", iotest.OneByteReader(bytes.NewReader([]byte(tt.data))))
+			if err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if info.Chunks != tt.chunks || info.Size != int64(len(tt.data)) {
+				t.Fatalf("chunks = %d, size = %d, want %d, %d", info.Chunks, info.Size, tt.chunks, len(tt.data))
+			}
+
+			r, _, err := store.Get(context.Background(), "obj")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			defer r.Close()
+			got, err := io.ReadAll(r)
+			if err != nil || string(got) != tt.data {
+				t.Fatalf("read %q, %v, want %q", got, err, tt.data)
+			}
+		})
+	}
+}
+
+// 数据源截断（返回 io.ErrUnexpectedEOF）时上传失败，不保存不完整的对象
+func TestLocalObjectsPutTruncatedSource(t *testing.T) {
+	store, backend := newTestLocalObjects(t)
+	src := io.MultiReader(bytes.NewReader([]byte("abcdef")), iotest.ErrReader(io.ErrUnexpectedEOF))
+
+	if _, err := store.Put(context.Background(), "obj", src); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Put error = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if _, err := store.Info(context.Background(), "obj"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("Info error = %v, want ErrObjectNotFound", err)
+	}
+	entries, err := os.ReadDir(backend.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("leftover files: %v", entries)
+	}
+}
@@ -0,0 +1,144 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// 协议版本
+// v1：消息体直接为数据模型（如 HeartbeatData），没有版本字段
+// v2：消息体为 Envelope 信封，数据模型位于 data 字段
+const (
+	ProtocolV1 = 1
+	ProtocolV2 = 2
+)
+
+// DefaultProtocolVersions 默认支持的协议版本，按优先级排序
+var DefaultProtocolVersions = []int{ProtocolV2, ProtocolV1}
+
+// Envelope 协议 v2 的消息信封，edge-agent 无需了解数据模型即可识别版本和类型
+type Envelope struct {
+	Version   int         `json:"v"`    // 协议版本
+	Type      string      `json:"type"` // 消息类型，见 MessageType* 常量
+	ID        string      `json:"id"`   // 消息 ID，与 Edge-Msg-Id 消息头相同
+	Timestamp int64       `json:"ts"`   // 发送时间（Unix 毫秒）
+	Data      interface{} `json:"data"` // 数据模型
+}
+
+// ProtocolHello 协议协商请求，App 启动和重连后发送到 hello 主题（始终使用 v1 格式）
+type ProtocolHello struct {
+	AppKey     string `json:"app_key"`
+	AppVersion string `json:"app_version,omitempty"`
+	Versions   []int  `json:"versions"` // App 支持的协议版本，按优先级排序
+}
+
+// ProtocolHelloReply 协议协商回复，edge-agent 从 Versions 中选择一个版本
+type ProtocolHelloReply struct {
+	Version int `json:"version"`
+}
+
+// validateProtocolVersions 检查协议版本配置
+func validateProtocolVersions(versions []int) error {
+	if len(versions) == 0 {
+		return fmt.Errorf("no protocol version configured")
+	}
+	for _, v := range versions {
+		if v != ProtocolV1 && v != ProtocolV2 {
+			return fmt.Errorf("unsupported protocol version: %d", v)
+		}
+	}
+	return nil
+}
+
+// supportsProtocol 是否支持指定协议版本
+func (c *Client) supportsProtocol(version int) bool {
+	for _, v := range c.opts.ProtocolVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackProtocol 协商前或协商失败（如旧版本 edge-agent 不支持协商）时使用的协议版本：
+// 支持 v1 时使用 v1，否则使用优先级最低的版本
+func (c *Client) fallbackProtocol() int {
+	if c.supportsProtocol(ProtocolV1) {
+		return ProtocolV1
+	}
+	return c.opts.ProtocolVersions[len(c.opts.ProtocolVersions)-1]
+}
+
+// ProtocolVersion 返回当前使用的协议版本
+func (c *Client) ProtocolVersion() int {
+	return int(c.protocol.Load())
+}
+
+// initProtocol 协商协议版本，并在每次（重新）连接后重新协商
+func (c *Client) initProtocol() {
+	c.protocol.Store(int32(c.fallbackProtocol()))
+	if len(c.opts.ProtocolVersions) == 1 {
+		// 只支持一个版本，无需协商
+		return
+	}
+
+	c.OnConnStateChange(func(change ConnStateChange) {
+		if change.State == ConnStateConnected {
+			go c.negotiateProtocol()
+		}
+	})
+	if c.transport.IsConnected() {
+		c.negotiateProtocol()
+	}
+}
+
+// negotiateProtocol 向 edge-agent 发送支持的协议版本，使用 edge-agent 选择的版本
+func (c *Client) negotiateProtocol() {
+	hello := ProtocolHello{
+		AppKey:     c.opts.AppKey,
+		AppVersion: c.opts.AppVersion,
+		Versions:   c.opts.ProtocolVersions,
+	}
+
+	version := c.fallbackProtocol()
+	msg, err := c.requestVersion(context.Background(), ProtocolV1, c.topics.Hello(), hello, c.opts.ProtocolNegotiationTimeout)
+	if err != nil {
+		c.logger.Infof("Protocol negotiation unavailable (%v), using protocol v%d", err, version)
+	} else {
+		var reply ProtocolHelloReply
+		switch err := c.decodeMessage(msg, &reply); {
+		case err != nil:
+			c.logger.Warnf("Invalid protocol negotiation reply: %v, using protocol v%d", err, version)
+		case !c.supportsProtocol(reply.Version):
+			c.logger.Warnf("Agent selected unsupported protocol v%d, using protocol v%d", reply.Version, version)
+		default:
+			version = reply.Version
+		}
+	}
+
+	if previous := c.protocol.Swap(int32(version)); int(previous) != version {
+		c.logger.Infof("Using protocol v%d", version)
+	}
+}
+
+// messageProtocol 消息头中的协议版本，未携带时为 v1
+func messageProtocol(msg *Message) (int, error) {
+	value := msg.Header.Get(HeaderSchemaVersion)
+	if value == "" {
+		return ProtocolV1, nil
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || (version != ProtocolV1 && version != ProtocolV2) {
+		return 0, fmt.Errorf("unsupported protocol version: %s", value)
+	}
+	return version, nil
+}
+
+// replyProtocol 回复请求使用的协议版本：与请求相同（如果支持），否则使用当前协议版本
+func (c *Client) replyProtocol(req *Message) int {
+	if version, err := messageProtocol(req); err == nil && c.supportsProtocol(version) {
+		return version
+	}
+	return c.ProtocolVersion()
+}
@@ -0,0 +1,361 @@
+package sdk
+
+//go:generate go run ./internal/schemagen -out ../schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Schema JSON Schema（draft 2020-12）
+type Schema map[string]interface{}
+
+const (
+	schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+	schemaBaseURL = "https://github.com/punk-one/edge-app-sdk/schema/"
+)
+
+// SchemaEnvelope Schemas 中协议 v2 信封的键
+const SchemaEnvelope = "envelope"
+
+// schemaEnums 字符串枚举类型的取值
+var schemaEnums = map[reflect.Type][]interface{}{
+	reflect.TypeOf(ConfigPhase("")): {ConfigPhaseValidated, ConfigPhaseSaved, ConfigPhaseApplied, ConfigPhaseRolledBack},
+}
+
+// GenerateSchema 根据 Go 类型生成 JSON Schema，字段名取自 json 标签
+// 未设置 omitempty 的字段为必填；map、切片和指针允许为 null，interface{} 不限制类型
+func GenerateSchema(v interface{}) Schema {
+	return typeSchema(reflect.TypeOf(v))
+}
+
+// typeSchema 生成类型的 JSON Schema
+func typeSchema(t reflect.Type) Schema {
+	if t == nil {
+		return Schema{}
+	}
+	if values, ok := schemaEnums[t]; ok {
+		return Schema{"type": "string", "enum": values}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(typeSchema(t.Elem()))
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Map:
+		schema := Schema{"type": "object"}
+		if elem := typeSchema(t.Elem()); len(elem) > 0 {
+			schema["additionalProperties"] = elem
+		}
+		return nullable(schema)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte 在 JSON 中编码为 base64 字符串
+			return nullable(Schema{"type": "string", "contentEncoding": "base64"})
+		}
+		return nullable(Schema{"type": "array", "items": typeSchema(t.Elem())})
+	case reflect.Array:
+		return Schema{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	default:
+		// interface{} 等
+		return Schema{}
+	}
+}
+
+// structSchema 生成结构体的 JSON Schema，匿名嵌入的结构体字段展开到外层
+func structSchema(t reflect.Type) Schema {
+	properties := Schema{}
+	var required []string
+
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				collect(field.Type)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = typeSchema(field.Type)
+			if !strings.Contains(","+opts+",", ",omitempty,") {
+				required = append(required, name)
+			}
+		}
+	}
+	collect(t)
+
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// nullable 允许值为 null
+func nullable(schema Schema) Schema {
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ, "null"}
+	}
+	return schema
+}
+
+// TopicDirection 消息方向
+type TopicDirection string
+
+const (
+	DirectionAppToAgent TopicDirection = "app_to_agent" // App 发送，edge-agent 接收
+	DirectionAgentToApp TopicDirection = "agent_to_app" // edge-agent 发送，App 接收
+	DirectionAppToApp   TopicDirection = "app_to_app"   // App 之间收发
+)
+
+// 主题规范中的占位符
+const (
+	topicAppKeyParam = "{app_key}"
+	topicStreamParam = "{stream}"
+)
+
+// TopicSpec 主题规范
+type TopicSpec struct {
+	Name        string         // 主题名称，即 app.<app_key>. 之后的部分，如 "config.set"
+	Direction   TopicDirection // 消息方向
+	Payload     interface{}    // 消息体数据模型，为 nil 表示 App 自定义的数据
+	Reply       interface{}    // 以 Request-Reply 方式发送时回复的数据模型，为 nil 表示只发布
+	RequestOnly bool           // 只以 Request-Reply 方式发送
+	Description string         // 说明
+
+	subject func(*TopicBuilder) string
+}
+
+// Subject 返回指定 App 的主题
+func (s TopicSpec) Subject(appKey string) string {
+	return s.subject(NewTopicBuilder(appKey))
+}
+
+// Address 返回主题模板，如 "app.{app_key}.heartbeat"
+func (s TopicSpec) Address() string {
+	return s.Subject(topicAppKeyParam)
+}
+
+// topicSpecs 与 TopicBuilder 的主题一一对应
+var topicSpecs = []TopicSpec{
+	{subject: (*TopicBuilder).Heartbeat, Direction: DirectionAppToAgent, Payload: HeartbeatData{},
+		Description: "心跳，按 HeartbeatInterval 定时发布，关闭时发布 status 为 stopped 的心跳；App 订阅所有心跳实现服务发现"},
+	{subject: (*TopicBuilder).Logs, Direction: DirectionAppToAgent, Payload: LogData{},
+		Description: "日志，级别不低于最小上报级别时发布"},
+	{subject: (*TopicBuilder).Events, Direction: DirectionAppToAgent, Payload: EventData{},
+		Description: "自定义事件"},
+	{subject: (*TopicBuilder).Status, Direction: DirectionAppToAgent, Payload: StatusData{},
+		Description: "应用状态"},
+	{subject: (*TopicBuilder).Command, Direction: DirectionAgentToApp, Payload: Command{}, Reply: CommandResult{},
+		Description: "命令，由 edge-agent 下发或其他 App 调用（Client.Call）；以请求方式发送时回复命令结果，以发布方式发送时结果发布到 cmd.result"},
+	{subject: (*TopicBuilder).CommandResult, Direction: DirectionAppToAgent, Payload: CommandResult{},
+		Description: "以发布方式下发的命令的执行结果"},
+	{subject: (*TopicBuilder).ConfigSet, Direction: DirectionAgentToApp, Payload: ConfigData{}, Reply: ConfigAck{},
+		Description: "配置下发；以请求方式发送时回复配置确认，以发布方式发送时确认发布到 config.ack"},
+	{subject: (*TopicBuilder).ConfigAck, Direction: DirectionAppToAgent, Payload: ConfigAck{},
+		Description: "以发布方式下发的配置的确认"},
+	{subject: (*TopicBuilder).ConfigGet, Direction: DirectionAppToAgent, Payload: ConfigRequest{}, Reply: ConfigData{}, RequestOnly: true,
+		Description: "配置拉取，启动和重连后发送；回复的 config 为空表示没有该 App 的配置"},
+	{subject: (*TopicBuilder).ConfigReport, Direction: DirectionAppToAgent, Payload: ConfigData{},
+		Description: "本地配置上报，配置文件被本地修改或配置同步时发现本地配置较新时发布"},
+	{subject: (*TopicBuilder).Hello, Direction: DirectionAppToAgent, Payload: ProtocolHello{}, Reply: ProtocolHelloReply{}, RequestOnly: true,
+		Description: "协议协商，启动和重连后发送，始终使用 v1 格式"},
+	{subject: func(tb *TopicBuilder) string { return tb.Data(topicStreamParam) }, Direction: DirectionAppToApp,
+		Description: "数据流，App 发布声明的数据流，其他 App 订阅；消息体为 App 自定义的数据"},
+}
+
+// Topics 返回所有主题的规范
+func Topics() []TopicSpec {
+	specs := make([]TopicSpec, len(topicSpecs))
+	for i, spec := range topicSpecs {
+		spec.Name = strings.TrimPrefix(spec.Address(), "app."+topicAppKeyParam+".")
+		specs[i] = spec
+	}
+	return specs
+}
+
+// Schemas 返回所有消息的 JSON Schema，键为消息类型（见 MessageType* 常量），SchemaEnvelope 为协议 v2 信封
+func Schemas() map[string]Schema {
+	schemas := map[string]Schema{}
+	add := func(name string, v interface{}, schema Schema) {
+		schema["$schema"] = schemaDialect
+		schema["$id"] = schemaBaseURL + name + ".schema.json"
+		schema["title"] = reflect.TypeOf(v).Name()
+		schemas[name] = schema
+	}
+
+	for _, spec := range topicSpecs {
+		for _, model := range []interface{}{spec.Payload, spec.Reply} {
+			if model != nil {
+				add(messageTypeOf(model), model, GenerateSchema(model))
+			}
+		}
+	}
+	add(SchemaEnvelope, Envelope{}, envelopeSchema(Schema{}))
+	return schemas
+}
+
+// envelopeSchema 协议 v2 信封的 JSON Schema，data 为数据模型的 JSON Schema
+func envelopeSchema(data Schema) Schema {
+	schema := GenerateSchema(Envelope{})
+	properties := schema["properties"].(Schema)
+	properties["v"] = Schema{"const": ProtocolV2}
+	properties["data"] = data
+	return schema
+}
+
+// AsyncAPI 生成描述所有主题的 AsyncAPI 3.0 文档（JSON），从 App 的角度描述收发操作
+func AsyncAPI() ([]byte, error) {
+	channels := Schema{}
+	operations := Schema{}
+	messages := Schema{}
+	schemas := Schema{"headers": headersSchema()}
+
+	for name, schema := range Schemas() {
+		component := Schema{}
+		for k, v := range schema {
+			if k != "$schema" && k != "$id" {
+				component[k] = v
+			}
+		}
+		schemas[name] = component
+	}
+
+	// message 注册消息并返回消息在组件中的引用，model 为 nil 表示 App 自定义的数据（数据流）
+	message := func(model interface{}) (string, Schema) {
+		msgType, title := MessageTypeData, "Data"
+		data := Schema{"description": "App 自定义的数据"}
+		if model != nil {
+			msgType, title = messageTypeOf(model), reflect.TypeOf(model).Name()
+			data = Schema{"$ref": "#/components/schemas/" + schemaRefName(msgType)}
+		}
+		messages[msgType] = Schema{
+			"name":    msgType,
+			"title":   title,
+			"headers": Schema{"$ref": "#/components/schemas/headers"},
+			"payload": Schema{
+				"anyOf": []Schema{
+					data,
+					{"allOf": []Schema{
+						{"$ref": "#/components/schemas/" + SchemaEnvelope},
+						{"properties": Schema{"data": data}},
+					}},
+				},
+			},
+		}
+		return msgType, Schema{"$ref": "#/components/messages/" + schemaRefName(msgType)}
+	}
+
+	for _, spec := range Topics() {
+		msgType, msgRef := message(spec.Payload)
+		channel := strings.NewReplacer("{", "", "}", "").Replace(spec.Name)
+		parameters := Schema{"app_key": Schema{"description": "App 标识"}}
+		if strings.Contains(spec.Address(), topicStreamParam) {
+			parameters["stream"] = Schema{"description": "数据流名称"}
+		}
+		channels[channel] = Schema{
+			"address":     spec.Address(),
+			"description": spec.Description,
+			"messages":    Schema{msgType: msgRef},
+			"parameters":  parameters,
+		}
+
+		var actions []string
+		switch spec.Direction {
+		case DirectionAppToAgent:
+			actions = []string{"send"}
+		case DirectionAgentToApp:
+			actions = []string{"receive"}
+		default:
+			actions = []string{"send", "receive"}
+		}
+		for _, action := range actions {
+			operations[action+"."+channel] = Schema{
+				"action":  action,
+				"channel": Schema{"$ref": "#/channels/" + schemaRefName(channel)},
+				"messages": []Schema{
+					{"$ref": "#/channels/" + schemaRefName(channel) + "/messages/" + schemaRefName(msgType)},
+				},
+			}
+		}
+		operation := operations[actions[0]+"."+channel].(Schema)
+		if spec.Reply != nil {
+			replyType, replyRef := message(spec.Reply)
+			replyChannel := spec.Name + ".reply"
+			channels[replyChannel] = Schema{
+				"address":     nil,
+				"description": "Request-Reply 的回复，发送到请求的回复主题",
+				"messages":    Schema{replyType: replyRef},
+			}
+			operation["reply"] = Schema{
+				"channel": Schema{"$ref": "#/channels/" + schemaRefName(replyChannel)},
+				"messages": []Schema{
+					{"$ref": "#/channels/" + schemaRefName(replyChannel) + "/messages/" + schemaRefName(replyType)},
+				},
+			}
+		}
+	}
+
+	doc := Schema{
+		"asyncapi": "3.0.0",
+		"info": Schema{
+			"title":   "Edge App Protocol",
+			"version": strconv.Itoa(ProtocolV2),
+			"description": "edge-agent 与 App 之间的消息协议。消息体编码由 Content-Type 消息头标识；" +
+				"协议 v1 的消息体为数据模型本身，v2 为信封，数据模型位于 data 字段，由 Edge-Schema-Version 消息头标识。",
+		},
+		"defaultContentType": ContentTypeJSON,
+		"channels":           channels,
+		"operations":         operations,
+		"components":         Schema{"messages": messages, "schemas": schemas},
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// schemaRefName 转义 JSON Pointer 中的特殊字符
+func schemaRefName(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+// headersSchema 标准消息头的 JSON Schema
+func headersSchema() Schema {
+	header := func(description string) Schema {
+		return Schema{"type": "string", "description": description}
+	}
+	return Schema{
+		"type": "object",
+		"properties": Schema{
+			HeaderContentType:   header("消息体编码，未设置时为 application/json"),
+			HeaderAccept:        header("请求方期望的回复编码（仅请求）"),
+			HeaderAppKey:        header("发送方 App 标识"),
+			HeaderAppVersion:    header("发送方 App 版本"),
+			HeaderMessageType:   header("消息类型"),
+			HeaderSchemaVersion: header("协议版本，未设置时为 1"),
+			HeaderMessageID:     header("消息 ID"),
+			HeaderTraceParent:   header("W3C Trace Context"),
+			HeaderTraceState:    header("W3C Trace Context 厂商扩展"),
+		},
+	}
+}
@@ -0,0 +1,245 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SchemaError 消息不符合 JSON Schema
+type SchemaError struct {
+	Violations []string // 不符合的位置和原因，如 "$.command_id: required property is missing"
+}
+
+func (e *SchemaError) Error() string {
+	return "message does not match schema: " + strings.Join(e.Violations, "; ")
+}
+
+// modelSchemas 数据模型类型对应的 JSON Schema 缓存
+var modelSchemas sync.Map // map[reflect.Type]Schema
+
+// modelSchema 返回数据模型类型（忽略指针）的 JSON Schema
+func modelSchema(model interface{}) (Schema, error) {
+	t := reflect.TypeOf(model)
+	if t == nil {
+		return nil, errors.New("model is nil")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if schema, ok := modelSchemas.Load(t); ok {
+		return schema.(Schema), nil
+	}
+	schema, _ := modelSchemas.LoadOrStore(t, typeSchema(t))
+	return schema.(Schema), nil
+}
+
+// ValidateMessage 按数据模型的 JSON Schema 校验消息体，model 为数据模型的值或指针（如 HeartbeatData{}、&cmd）
+// 消息体按 Content-Type 解码，协议 v2 的消息同时校验信封；不符合时返回 *SchemaError
+func ValidateMessage(msg *Message, model interface{}) error {
+	schema, err := modelSchema(model)
+	if err != nil {
+		return err
+	}
+	codec, err := messageCodec(msg)
+	if err != nil {
+		return err
+	}
+	version, err := messageProtocol(msg)
+	if err != nil {
+		return err
+	}
+	if version == ProtocolV2 {
+		schema = envelopeSchema(schema)
+	}
+
+	var body interface{}
+	if err := codec.Unmarshal(msg.Data, &body); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+	// 统一为 JSON 数据模型，与编码无关
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	if violations := validateSchema(schema, body, "$"); len(violations) > 0 {
+		return &SchemaError{Violations: violations}
+	}
+	return nil
+}
+
+// validateSchema 校验值是否符合 JSON Schema，返回不符合的位置和原因
+// 支持 GenerateSchema 生成的关键字：type、enum、const、properties、required、additionalProperties、items、anyOf、allOf
+func validateSchema(schema Schema, value interface{}, path string) []string {
+	var violations []string
+
+	if branches, ok := schema["anyOf"].([]Schema); ok {
+		matched := false
+		for _, branch := range branches {
+			if len(validateSchema(branch, value, path)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			violations = append(violations, path+": does not match any allowed schema")
+		}
+	}
+	if branches, ok := schema["allOf"].([]Schema); ok {
+		for _, branch := range branches {
+			violations = append(violations, validateSchema(branch, value, path)...)
+		}
+	}
+
+	if expected, ok := schema["const"]; ok && !jsonEqual(expected, value) {
+		violations = append(violations, fmt.Sprintf("%s: must be %v", path, expected))
+	}
+	if values, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, v := range values {
+			if jsonEqual(v, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			violations = append(violations, fmt.Sprintf("%s: must be one of %v", path, values))
+		}
+	}
+
+	if types := schemaTypes(schema); len(types) > 0 {
+		actual := jsonType(value)
+		if !typeAllowed(types, actual, value) {
+			return append(violations, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(types, " or "), actual))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(Schema)
+		if required, ok := schema["required"].([]string); ok {
+			for _, name := range required {
+				if _, ok := v[name]; !ok {
+					violations = append(violations, path+"."+name+": required property is missing")
+				}
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := properties[name].(Schema); ok {
+				violations = append(violations, validateSchema(property, v[name], path+"."+name)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case Schema:
+				violations = append(violations, validateSchema(additional, v[name], path+"."+name)...)
+			case bool:
+				if !additional {
+					violations = append(violations, path+"."+name+": unknown property")
+				}
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(Schema); ok {
+			for i, item := range v {
+				violations = append(violations, validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return violations
+}
+
+// schemaTypes 返回 type 关键字允许的类型
+func schemaTypes(schema Schema) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	default:
+		return nil
+	}
+}
+
+// jsonType 返回 JSON 值的类型名称
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// typeAllowed 值的类型是否在允许的类型中，integer 匹配没有小数部分的数字
+func typeAllowed(types []string, actual string, value interface{}) bool {
+	for _, t := range types {
+		if t == actual {
+			return true
+		}
+		if t == "integer" && actual == "number" {
+			if f, err := value.(json.Number).Float64(); err == nil && f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonEqual 比较两个值的 JSON 编码是否相同
+func jsonEqual(a, b interface{}) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(x, y)
+}
+
+// validateMessage 校验收发的消息（Options.ValidateMessages），不符合时记录警告
+func (c *Client) validateMessage(direction string, msg *Message, model interface{}) {
+	if err := ValidateMessage(msg, model); err != nil {
+		msgType := messageTypeOf(model)
+		if msgType == "" {
+			msgType = msg.Header.Get(HeaderMessageType)
+		}
+		c.logger.Warnf("Invalid %s %s message: %v", direction, msgType, err)
+	}
+}
+
+// decodeMessage 解码收到的消息，开启 Options.ValidateMessages 时先校验消息体
+func (c *Client) decodeMessage(msg *Message, v interface{}) error {
+	if c.opts.ValidateMessages {
+		c.validateMessage("incoming", msg, v)
+	}
+	return DecodeMessage(msg, v)
+}
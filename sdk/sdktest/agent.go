@@ -0,0 +1,569 @@
+package sdktest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/punk-one/edge-app-sdk/sdk"
+)
+
+// DefaultTimeout Agent 等待回复和消息的默认超时时间
+const DefaultTimeout = 5 * time.Second
+
+// Agent 进程内的模拟 edge-agent
+// 下发命令和配置、应答 config.get，并记录 App 上报的心跳、日志、事件、状态等消息。
+// 发送和等待方法在失败或超时时调用 t.Fatalf，因此只能在测试 goroutine 中调用
+type Agent struct {
+	t         testing.TB
+	transport *Transport
+	topics    *sdk.TopicBuilder
+
+	mu       sync.Mutex
+	timeout  time.Duration
+	codec    sdk.Codec
+	config   sdk.ConfigData
+	nextID   int
+	versions []int            // Agent 支持的协议版本，按优先级排序
+	protocol int              // 与 App 协商的协议版本
+	helloSub sdk.Subscription // 协议协商订阅，模拟旧版本 edge-agent 时取消
+
+	heartbeats     recorder[sdk.HeartbeatData]
+	logs           recorder[sdk.LogData]
+	events         recorder[sdk.EventData]
+	statuses       recorder[sdk.StatusData]
+	commandResults recorder[sdk.CommandResult]
+	configAcks     recorder[sdk.ConfigAck]
+	configReports  recorder[sdk.ConfigData]
+	configRequests recorder[sdk.ConfigRequest]
+	hellos         recorder[sdk.ProtocolHello]
+}
+
+// NewAgent 创建连接到 bus 的模拟 edge-agent，负责 appKey 对应的 App
+func NewAgent(t testing.TB, bus *Bus, appKey string) *Agent {
+	t.Helper()
+
+	a := &Agent{
+		t:         t,
+		transport: bus.Connect(),
+		topics:    sdk.NewTopicBuilder(appKey),
+		timeout:   DefaultTimeout,
+		codec:     sdk.JSONCodec,
+		versions:  append([]int(nil), sdk.DefaultProtocolVersions...),
+		protocol:  sdk.ProtocolV1,
+	}
+
+	subs := map[string]sdk.MessageHandler{
+		a.topics.Heartbeat():     record(&a.heartbeats),
+		a.topics.Logs():          record(&a.logs),
+		a.topics.Events():        record(&a.events),
+		a.topics.Status():        record(&a.statuses),
+		a.topics.CommandResult(): record(&a.commandResults),
+		a.topics.ConfigAck():     record(&a.configAcks),
+		a.topics.ConfigReport():  record(&a.configReports),
+		a.topics.ConfigGet():     a.handleConfigGet,
+	}
+	for subject, handler := range subs {
+		if _, err := a.transport.Subscribe(subject, handler); err != nil {
+			t.Fatalf("sdktest: failed to subscribe to %s: %v", subject, err)
+		}
+	}
+	helloSub, err := a.transport.Subscribe(a.topics.Hello(), a.handleHello)
+	if err != nil {
+		t.Fatalf("sdktest: failed to subscribe to %s: %v", a.topics.Hello(), err)
+	}
+	a.helloSub = helloSub
+
+	return a
+}
+
+// Topics 返回 App 的主题构建器
+func (a *Agent) Topics() *sdk.TopicBuilder {
+	return a.topics
+}
+
+// Transport 返回 Agent 使用的传输层，可用于发送自定义消息
+func (a *Agent) Transport() *Transport {
+	return a.transport
+}
+
+// SetTimeout 设置等待回复和消息的超时时间
+func (a *Agent) SetTimeout(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.timeout = d
+}
+
+// SetCodec 设置 Agent 下发命令和配置使用的编码，默认 JSON
+// 接收的消息按 Content-Type 解码，应答 config.get 时使用 App 请求的编码
+func (a *Agent) SetCodec(codec sdk.Codec) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.codec = codec
+}
+
+// SetProtocolVersions 设置 Agent 支持的协议版本（按优先级排序），默认 [2, 1]
+// 不传参数时模拟不支持协商的旧版本 edge-agent：App 的协商请求没有响应者，App 使用 v1
+func (a *Agent) SetProtocolVersions(versions ...int) {
+	a.t.Helper()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.versions = append([]int(nil), versions...)
+	switch {
+	case len(versions) == 0 && a.helloSub != nil:
+		a.helloSub.Unsubscribe()
+		a.helloSub = nil
+		a.protocol = sdk.ProtocolV1
+	case len(versions) > 0 && a.helloSub == nil:
+		sub, err := a.transport.Subscribe(a.topics.Hello(), a.handleHello)
+		if err != nil {
+			a.t.Fatalf("sdktest: failed to subscribe to %s: %v", a.topics.Hello(), err)
+		}
+		a.helloSub = sub
+	}
+}
+
+// ProtocolVersion 返回与 App 协商的协议版本，Agent 下发命令和配置使用该版本
+func (a *Agent) ProtocolVersion() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.protocol
+}
+
+// ProtocolHellos 返回收到的协议协商请求
+func (a *Agent) ProtocolHellos() []sdk.ProtocolHello {
+	return a.hellos.snapshot()
+}
+
+// SetConfig 设置 config.get 返回的权威配置；未设置时返回空配置（表示 edge-agent 没有该 App 的配置）
+func (a *Agent) SetConfig(data sdk.ConfigData) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.config = data
+}
+
+// SendCommand 以请求-回复方式下发命令并返回执行结果，CommandID 为空时自动生成
+func (a *Agent) SendCommand(cmd sdk.Command) sdk.CommandResult {
+	a.t.Helper()
+
+	if cmd.CommandID == "" {
+		cmd.CommandID = a.newID("cmd")
+	}
+	var result sdk.CommandResult
+	a.request(a.topics.Command(), cmd, &result)
+	return result
+}
+
+// PublishCommand 以发布方式下发命令（结果发布到 cmd.result 主题），返回 CommandID
+func (a *Agent) PublishCommand(cmd sdk.Command) string {
+	a.t.Helper()
+
+	if cmd.CommandID == "" {
+		cmd.CommandID = a.newID("cmd")
+	}
+	a.publish(a.topics.Command(), cmd)
+	return cmd.CommandID
+}
+
+// AwaitCommandResult 等待指定命令发布到 cmd.result 主题的执行结果
+func (a *Agent) AwaitCommandResult(commandID string) sdk.CommandResult {
+	a.t.Helper()
+	return await(a, &a.commandResults, "command result "+commandID, func(r sdk.CommandResult) bool {
+		return r.CommandID == commandID
+	})
+}
+
+// SendConfig 以请求-回复方式下发配置并返回配置确认，CorrelationID 为空时自动生成
+func (a *Agent) SendConfig(data sdk.ConfigData) sdk.ConfigAck {
+	a.t.Helper()
+
+	a.fillConfigData(&data)
+	var ack sdk.ConfigAck
+	a.request(a.topics.ConfigSet(), data, &ack)
+	return ack
+}
+
+// PushConfig 以发布方式下发配置（确认发布到 config.ack 主题），返回 CorrelationID
+func (a *Agent) PushConfig(data sdk.ConfigData) string {
+	a.t.Helper()
+
+	a.fillConfigData(&data)
+	a.publish(a.topics.ConfigSet(), data)
+	return data.CorrelationID
+}
+
+// AwaitConfigAck 等待指定配置发布到 config.ack 主题的确认
+func (a *Agent) AwaitConfigAck(correlationID string) sdk.ConfigAck {
+	a.t.Helper()
+	return await(a, &a.configAcks, "config ack "+correlationID, func(ack sdk.ConfigAck) bool {
+		return ack.CorrelationID == correlationID
+	})
+}
+
+// Heartbeats 返回已收到的心跳
+func (a *Agent) Heartbeats() []sdk.HeartbeatData {
+	return a.heartbeats.snapshot()
+}
+
+// AwaitHeartbeats 等待至少收到 n 个心跳，返回已收到的全部心跳
+func (a *Agent) AwaitHeartbeats(n int) []sdk.HeartbeatData {
+	a.t.Helper()
+
+	items, ok := a.heartbeats.wait(a.getTimeout(), func(items []sdk.HeartbeatData) bool {
+		return len(items) >= n
+	})
+	if !ok {
+		a.t.Fatalf("sdktest: timed out waiting for %d heartbeats, got %d", n, len(items))
+	}
+	return items
+}
+
+// Logs 返回已收到的日志
+func (a *Agent) Logs() []sdk.LogData {
+	return a.logs.snapshot()
+}
+
+// AwaitLog 等待第一条满足 match 的日志（match 为 nil 时匹配任意日志）
+func (a *Agent) AwaitLog(match func(sdk.LogData) bool) sdk.LogData {
+	a.t.Helper()
+	return await(a, &a.logs, "log", match)
+}
+
+// Events 返回已收到的事件
+func (a *Agent) Events() []sdk.EventData {
+	return a.events.snapshot()
+}
+
+// AwaitEvent 等待第一个名为 event 的事件
+func (a *Agent) AwaitEvent(event string) sdk.EventData {
+	a.t.Helper()
+	return await(a, &a.events, "event "+event, func(e sdk.EventData) bool {
+		return e.Event == event
+	})
+}
+
+// Statuses 返回已收到的状态上报
+func (a *Agent) Statuses() []sdk.StatusData {
+	return a.statuses.snapshot()
+}
+
+// AwaitStatus 等待第一个满足 match 的状态上报（match 为 nil 时匹配任意状态）
+func (a *Agent) AwaitStatus(match func(sdk.StatusData) bool) sdk.StatusData {
+	a.t.Helper()
+	return await(a, &a.statuses, "status", match)
+}
+
+// ConfigReports 返回 App 上报的本地配置
+func (a *Agent) ConfigReports() []sdk.ConfigData {
+	return a.configReports.snapshot()
+}
+
+// AwaitConfigReport 等待第一个满足 match 的本地配置上报（match 为 nil 时匹配任意上报）
+func (a *Agent) AwaitConfigReport(match func(sdk.ConfigData) bool) sdk.ConfigData {
+	a.t.Helper()
+	return await(a, &a.configReports, "config report", match)
+}
+
+// ConfigRequests 返回 App 发起的配置拉取请求
+func (a *Agent) ConfigRequests() []sdk.ConfigRequest {
+	return a.configRequests.snapshot()
+}
+
+// Reset 清空已记录的消息
+func (a *Agent) Reset() {
+	a.heartbeats.reset()
+	a.logs.reset()
+	a.events.reset()
+	a.statuses.reset()
+	a.commandResults.reset()
+	a.configAcks.reset()
+	a.configReports.reset()
+	a.configRequests.reset()
+	a.hellos.reset()
+}
+
+// Close 关闭 Agent
+func (a *Agent) Close() {
+	a.transport.Close()
+}
+
+// handleConfigGet 应答配置拉取请求
+func (a *Agent) handleConfigGet(msg *sdk.Message) {
+	var req sdk.ConfigRequest
+	if err := sdk.DecodeMessage(msg, &req); err == nil {
+		a.configRequests.add(req)
+	}
+
+	a.mu.Lock()
+	config := a.config
+	a.mu.Unlock()
+
+	a.reply(msg, config)
+}
+
+// handleHello 应答协议协商请求：按 Agent 的优先级选择双方都支持的版本
+func (a *Agent) handleHello(msg *sdk.Message) {
+	var hello sdk.ProtocolHello
+	if err := sdk.DecodeMessage(msg, &hello); err != nil {
+		return
+	}
+	a.hellos.add(hello)
+
+	a.mu.Lock()
+	selected := 0
+	for _, v := range a.versions {
+		if containsVersion(hello.Versions, v) {
+			selected = v
+			break
+		}
+	}
+	if selected != 0 {
+		a.protocol = selected
+	}
+	a.mu.Unlock()
+
+	a.reply(msg, sdk.ProtocolHelloReply{Version: selected})
+}
+
+// reply 回复 App 的请求，编码和协议版本与请求一致
+func (a *Agent) reply(req *sdk.Message, v interface{}) {
+	codec := a.getCodec()
+	if accepted, ok := sdk.CodecFor(req.Header.Get(sdk.HeaderAccept)); ok {
+		codec = accepted
+	}
+	version := sdk.ProtocolV1
+	if req.Header.Get(sdk.HeaderSchemaVersion) == strconv.Itoa(sdk.ProtocolV2) {
+		version = sdk.ProtocolV2
+	}
+
+	msg, err := encodeMessage(codec, version, v)
+	if err != nil {
+		return
+	}
+	a.transport.Respond(req, msg)
+}
+
+// containsVersion 判断版本列表中是否包含指定版本
+func containsVersion(versions []int, version int) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// fillConfigData 填充 CorrelationID 和时间戳
+func (a *Agent) fillConfigData(data *sdk.ConfigData) {
+	if data.CorrelationID == "" {
+		data.CorrelationID = a.newID("config")
+	}
+	if data.Timestamp == 0 {
+		data.Timestamp = time.Now().Unix()
+	}
+}
+
+// publish 序列化并发布消息
+func (a *Agent) publish(subject string, v interface{}) {
+	a.t.Helper()
+
+	msg, err := encodeMessage(a.getCodec(), a.ProtocolVersion(), v)
+	if err != nil {
+		a.t.Fatalf("sdktest: failed to marshal %s: %v", subject, err)
+	}
+	msg.Subject = subject
+	if err := a.transport.Publish(msg); err != nil {
+		a.t.Fatalf("sdktest: failed to publish %s: %v", subject, err)
+	}
+}
+
+// request 序列化并发送请求，将回复解析到 out
+func (a *Agent) request(subject string, v interface{}, out interface{}) {
+	a.t.Helper()
+
+	codec := a.getCodec()
+	msg, err := encodeMessage(codec, a.ProtocolVersion(), v)
+	if err != nil {
+		a.t.Fatalf("sdktest: failed to marshal %s: %v", subject, err)
+	}
+	msg.Subject = subject
+	msg.Header.Set(sdk.HeaderAccept, codec.ContentType())
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.getTimeout())
+	defer cancel()
+
+	reply, err := a.transport.Request(ctx, msg)
+	if err != nil {
+		a.t.Fatalf("sdktest: request %s failed: %v", subject, err)
+	}
+	if err := sdk.DecodeMessage(reply, out); err != nil {
+		a.t.Fatalf("sdktest: failed to unmarshal reply from %s: %v", subject, err)
+	}
+}
+
+// encodeMessage 按指定编码和协议版本序列化消息体，设置 Content-Type 和 edge-agent 发送的标准消息头
+// Command 和 ConfigData 的 Metadata 中设置的追踪上下文和自定义消息头会一并发送
+func encodeMessage(codec sdk.Codec, version int, v interface{}) (*sdk.Message, error) {
+	header := sdk.Header{}
+	var meta sdk.Metadata
+	switch v := v.(type) {
+	case sdk.Command:
+		header.Set(sdk.HeaderMessageType, sdk.MessageTypeCommand)
+		meta = v.Metadata
+	case sdk.ConfigData:
+		header.Set(sdk.HeaderMessageType, sdk.MessageTypeConfig)
+		meta = v.Metadata
+	}
+	for k, values := range meta.Header {
+		header[k] = append([]string(nil), values...)
+	}
+	if meta.TraceParent != "" {
+		header.Set(sdk.HeaderTraceParent, meta.TraceParent)
+	}
+	if meta.TraceState != "" {
+		header.Set(sdk.HeaderTraceState, meta.TraceState)
+	}
+	header.Set(sdk.HeaderSchemaVersion, strconv.Itoa(version))
+	header.Set(sdk.HeaderMessageID, newMessageID())
+	header.Set(sdk.HeaderContentType, codec.ContentType())
+
+	body := v
+	if version == sdk.ProtocolV2 {
+		body = sdk.Envelope{
+			Version:   version,
+			Type:      header.Get(sdk.HeaderMessageType),
+			ID:        header.Get(sdk.HeaderMessageID),
+			Timestamp: time.Now().UnixMilli(),
+			Data:      v,
+		}
+	}
+	data, err := codec.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &sdk.Message{Header: header, Data: data}, nil
+}
+
+// newMessageID 生成消息 ID
+func newMessageID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// newID 生成 ID
+func (a *Agent) newID(prefix string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nextID++
+	return fmt.Sprintf("%s-%d", prefix, a.nextID)
+}
+
+// getCodec 获取编码
+func (a *Agent) getCodec() sdk.Codec {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.codec
+}
+
+// getTimeout 获取超时时间
+func (a *Agent) getTimeout() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.timeout
+}
+
+// await 等待 recorder 中第一个满足 match 的消息
+func await[T any](a *Agent, r *recorder[T], what string, match func(T) bool) T {
+	a.t.Helper()
+
+	var found T
+	_, ok := r.wait(a.getTimeout(), func(items []T) bool {
+		for _, item := range items {
+			if match == nil || match(item) {
+				found = item
+				return true
+			}
+		}
+		return false
+	})
+	if !ok {
+		a.t.Fatalf("sdktest: timed out waiting for %s", what)
+	}
+	return found
+}
+
+// recorder 记录某一类消息
+type recorder[T any] struct {
+	mu      sync.Mutex
+	items   []T
+	changed chan struct{}
+}
+
+// record 返回解析消息并写入 recorder 的处理函数
+func record[T any](r *recorder[T]) sdk.MessageHandler {
+	return func(msg *sdk.Message) {
+		var item T
+		if err := sdk.DecodeMessage(msg, &item); err != nil {
+			return
+		}
+		r.add(item)
+	}
+}
+
+// add 记录消息并唤醒等待者
+func (r *recorder[T]) add(item T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items = append(r.items, item)
+	if r.changed != nil {
+		close(r.changed)
+		r.changed = nil
+	}
+}
+
+// snapshot 返回已记录消息的副本
+func (r *recorder[T]) snapshot() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]T(nil), r.items...)
+}
+
+// reset 清空记录
+func (r *recorder[T]) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items = nil
+}
+
+// wait 等待 cond 对已记录消息成立，超时返回 false
+func (r *recorder[T]) wait(timeout time.Duration, cond func([]T) bool) ([]T, bool) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		r.mu.Lock()
+		items := append([]T(nil), r.items...)
+		if cond(items) {
+			r.mu.Unlock()
+			return items, true
+		}
+		if r.changed == nil {
+			r.changed = make(chan struct{})
+		}
+		changed := r.changed
+		r.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return items, false
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package sdktest_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/punk-one/edge-app-sdk/sdk"
+	"github.com/punk-one/edge-app-sdk/sdk/sdktest"
+)
+
+// newTestAgent 创建 Client 和超时较短的 Agent
+func newTestAgent(t *testing.T, opts sdk.Options) (*sdk.Client, *sdktest.Agent) {
+	t.Helper()
+	client, agent := sdktest.New(t, opts)
+	agent.SetTimeout(2 * time.Second)
+	return client, agent
+}
+
+func TestAgentPublishCommand(t *testing.T) {
+	client, agent := newTestAgent(t, sdk.Options{})
+	client.OnCommand(func(cmd sdk.Command) sdk.CommandResult {
+		return sdk.CommandResult{Success: cmd.Action == "start", Message: cmd.Action}
+	})
+
+	start := agent.PublishCommand(sdk.Command{Action: "start"})
+	stop := agent.PublishCommand(sdk.Command{Action: "stop", CommandID: "cmd-stop"})
+	if stop != "cmd-stop" {
+		t.Errorf("PublishCommand() = %q, want the given CommandID", stop)
+	}
+
+	// 按 CommandID 等待，与结果到达的顺序无关
+	if result := agent.AwaitCommandResult(stop); result.Success || result.Message != "stop" {
+		t.Errorf("stop result = %+v", result)
+	}
+	if result := agent.AwaitCommandResult(start); !result.Success || result.CommandID != start {
+		t.Errorf("start result = %+v", result)
+	}
+}
+
+func TestAgentPushConfig(t *testing.T) {
+	client, agent := newTestAgent(t, sdk.Options{})
+	client.OnConfig(func(cfg map[string]interface{}) error {
+		if cfg["fps"] == float64(0) {
+			return errors.New("fps must be positive")
+		}
+		return nil
+	})
+
+	id := agent.PushConfig(sdk.ConfigData{Config: map[string]interface{}{"fps": 30}, Version: "1"})
+	if ack := agent.AwaitConfigAck(id); !ack.Success || ack.Phase != sdk.ConfigPhaseApplied || ack.Version != "1" {
+		t.Errorf("ack = %+v", ack)
+	}
+
+	id = agent.PushConfig(sdk.ConfigData{Config: map[string]interface{}{"fps": 0}, Version: "2"})
+	ack := agent.AwaitConfigAck(id)
+	if ack.Success || ack.Phase != sdk.ConfigPhaseRolledBack || ack.Error == nil || ack.Error.Code != sdk.ConfigErrApplyFailed {
+		t.Errorf("ack = %+v", ack)
+	}
+}
+
+func TestAgentConfigSync(t *testing.T) {
+	bus := sdktest.NewBus()
+	agent := sdktest.NewAgent(t, bus, "app.camera")
+	t.Cleanup(agent.Close)
+	agent.SetTimeout(2 * time.Second)
+
+	// 启动时 config.get 返回 SetConfig 设置的配置，App 应用它
+	agent.SetConfig(sdk.ConfigData{Config: map[string]interface{}{"fps": 15}, Version: "3"})
+	client, err := sdk.NewClient(sdk.Options{AppKey: "app.camera", ConfigDir: t.TempDir(), Transport: bus.Connect()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	got := make(chan map[string]interface{}, 1)
+	client.OnConfig(func(cfg map[string]interface{}) error {
+		got <- cfg
+		return nil
+	})
+
+	select {
+	case cfg := <-got:
+		if cfg["fps"] != float64(15) {
+			t.Errorf("synced config = %v", cfg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("config from config.get not applied")
+	}
+	requests := agent.ConfigRequests()
+	if len(requests) != 1 || requests[0].AppKey != "app.camera" || requests[0].Version != "" {
+		t.Fatalf("ConfigRequests() = %+v", requests)
+	}
+
+	// 本地配置较新时 App 将本地配置上报给 Agent
+	agent.SendConfig(sdk.ConfigData{Config: map[string]interface{}{"fps": 60}, Version: "5"})
+	<-got
+	if err := client.SyncConfig(); err != nil {
+		t.Fatalf("SyncConfig() error = %v", err)
+	}
+	report := agent.AwaitConfigReport(nil)
+	if report.Version != "5" || report.Config["fps"] != float64(60) {
+		t.Errorf("config report = %+v", report)
+	}
+	if requests := agent.ConfigRequests(); requests[len(requests)-1].Version != "5" {
+		t.Errorf("request carried version %q, want 5", requests[len(requests)-1].Version)
+	}
+}
+
+func TestAgentRecords(t *testing.T) {
+	client, agent := newTestAgent(t, sdk.Options{})
+
+	client.LogWarn("disk almost full")
+	client.ReportStatus(map[string]interface{}{"fps": 25})
+
+	log := agent.AwaitLog(func(l sdk.LogData) bool { return l.Message == "disk almost full" })
+	if log.Level != string(sdk.LogLevelWarn) {
+		t.Errorf("log level = %q, want %q", log.Level, sdk.LogLevelWarn)
+	}
+	status := agent.AwaitStatus(nil)
+	if status.AppKey != "app.test" || status.Data["fps"] != float64(25) {
+		t.Errorf("status = %+v", status)
+	}
+
+	agent.Reset()
+	if n := len(agent.Logs()) + len(agent.Statuses()) + len(agent.Heartbeats()); n != 0 {
+		t.Errorf("%d messages recorded after Reset", n)
+	}
+}
+
+func TestAgentProtocolVersions(t *testing.T) {
+	t.Run("negotiated", func(t *testing.T) {
+		client, agent := newTestAgent(t, sdk.Options{})
+		if client.ProtocolVersion() != sdk.ProtocolV2 || agent.ProtocolVersion() != sdk.ProtocolV2 {
+			t.Errorf("protocol = client %d, agent %d, want 2", client.ProtocolVersion(), agent.ProtocolVersion())
+		}
+		if hellos := agent.ProtocolHellos(); len(hellos) != 1 {
+			t.Errorf("ProtocolHellos() = %+v, want one hello", hellos)
+		}
+	})
+
+	t.Run("legacy agent", func(t *testing.T) {
+		bus := sdktest.NewBus()
+		agent := sdktest.NewAgent(t, bus, "app.test")
+		t.Cleanup(agent.Close)
+		agent.SetTimeout(2 * time.Second)
+		agent.SetProtocolVersions()
+
+		client, err := sdk.NewClient(sdk.Options{AppKey: "app.test", ConfigDir: t.TempDir(), Transport: bus.Connect()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { client.Close() })
+		client.OnCommand(func(cmd sdk.Command) sdk.CommandResult {
+			return sdk.CommandResult{Success: true}
+		})
+
+		if client.ProtocolVersion() != sdk.ProtocolV1 || agent.ProtocolVersion() != sdk.ProtocolV1 {
+			t.Errorf("protocol = client %d, agent %d, want 1", client.ProtocolVersion(), agent.ProtocolVersion())
+		}
+		if result := agent.SendCommand(sdk.Command{Action: "ping"}); !result.Success {
+			t.Errorf("SendCommand() over v1 = %+v", result)
+		}
+	})
+}
+
+func TestAgentCodec(t *testing.T) {
+	client, agent := newTestAgent(t, sdk.Options{})
+	agent.SetCodec(sdk.MsgPackCodec)
+	client.OnCommand(func(cmd sdk.Command) sdk.CommandResult {
+		return sdk.CommandResult{Success: cmd.Payload["n"] != nil, Data: cmd.Payload}
+	})
+
+	result := agent.SendCommand(sdk.Command{Action: "echo", Payload: map[string]interface{}{"n": 1}})
+	if !result.Success {
+		t.Errorf("SendCommand() with msgpack = %+v", result)
+	}
+}
@@ -0,0 +1,416 @@
+package sdktest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/punk-one/edge-app-sdk/sdk"
+)
+
+var (
+	// ErrNoResponders 请求主题上没有订阅者（errors.Is 匹配 sdk.ErrNoResponders）
+	ErrNoResponders = fmt.Errorf("sdktest: %w", sdk.ErrNoResponders)
+	// ErrNotConnected 传输层已断开或已关闭
+	ErrNotConnected = errors.New("sdktest: transport not connected")
+	// ErrSimulatedDisconnect Transport.Disconnect 模拟断线时上报的断开原因
+	ErrSimulatedDisconnect = errors.New("sdktest: simulated disconnect")
+)
+
+// Bus 进程内消息总线，模拟 NATS 的主题匹配（* 和 > 通配符）、队列组和请求-回复
+type Bus struct {
+	mu     sync.Mutex
+	subs   map[*subscription]struct{}
+	queues map[string]int // 队列组轮询计数
+	inbox  int
+}
+
+// NewBus 创建消息总线
+func NewBus() *Bus {
+	return &Bus{
+		subs:   make(map[*subscription]struct{}),
+		queues: make(map[string]int),
+	}
+}
+
+// Connect 创建连接到总线的传输层，可作为 sdk.Options.Transport 使用
+func (b *Bus) Connect() *Transport {
+	return &Transport{bus: b, connected: true}
+}
+
+// publish 将消息投递给所有匹配的订阅者，同一队列组中只投递给一个订阅者，返回投递数量
+func (b *Bus) publish(msg *sdk.Message) int {
+	b.mu.Lock()
+	var targets []*subscription
+	groups := make(map[string][]*subscription)
+	for sub := range b.subs {
+		if !sub.transport.IsConnected() || !subjectMatches(sub.subject, msg.Subject) {
+			continue
+		}
+		if sub.queue == "" {
+			targets = append(targets, sub)
+		} else {
+			key := sub.subject + " " + sub.queue
+			groups[key] = append(groups[key], sub)
+		}
+	}
+	for key, members := range groups {
+		n := b.queues[key]
+		b.queues[key] = n + 1
+		targets = append(targets, members[n%len(members)])
+	}
+	b.mu.Unlock()
+
+	for _, sub := range targets {
+		sub.deliver(copyMessage(msg))
+	}
+	return len(targets)
+}
+
+// newInbox 生成唯一的回复主题
+func (b *Bus) newInbox() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inbox++
+	return "_INBOX." + strconv.Itoa(b.inbox)
+}
+
+// addSubscription 注册订阅
+func (b *Bus) addSubscription(sub *subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[sub] = struct{}{}
+}
+
+// removeSubscription 移除订阅
+func (b *Bus) removeSubscription(sub *subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, sub)
+}
+
+// Transport 连接到 Bus 的 sdk.Transport 实现
+type Transport struct {
+	bus *Bus
+
+	mu               sync.Mutex
+	connected        bool
+	closed           bool
+	subs             []*subscription
+	reconnectHandler func()
+	stateHandler     func(state sdk.ConnState, err error)
+
+	// 连接统计
+	inMsgs, outMsgs, inBytes, outBytes, reconnects atomic.Uint64
+}
+
+// Publish 发布消息
+func (t *Transport) Publish(msg *sdk.Message) error {
+	if !t.IsConnected() {
+		return ErrNotConnected
+	}
+	t.countOut(msg)
+	t.bus.publish(msg)
+	return nil
+}
+
+// countOut 统计发出的消息
+func (t *Transport) countOut(msg *sdk.Message) {
+	t.outMsgs.Add(1)
+	t.outBytes.Add(uint64(len(msg.Data)))
+}
+
+// Subscribe 订阅主题
+func (t *Transport) Subscribe(subject string, handler sdk.MessageHandler) (sdk.Subscription, error) {
+	return t.subscribe(subject, "", handler)
+}
+
+// QueueSubscribe 队列订阅
+func (t *Transport) QueueSubscribe(subject, queue string, handler sdk.MessageHandler) (sdk.Subscription, error) {
+	return t.subscribe(subject, queue, handler)
+}
+
+// subscribe 创建订阅，每个订阅按顺序在独立的 goroutine 中处理消息
+func (t *Transport) subscribe(subject, queue string, handler sdk.MessageHandler) (*subscription, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, ErrNotConnected
+	}
+
+	sub := &subscription{
+		transport: t,
+		subject:   subject,
+		queue:     queue,
+		handler:   handler,
+		notify:    make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	go sub.run()
+
+	t.subs = append(t.subs, sub)
+	t.bus.addSubscription(sub)
+	return sub, nil
+}
+
+// Request 发送请求并等待回复
+func (t *Transport) Request(ctx context.Context, msg *sdk.Message) (*sdk.Message, error) {
+	if !t.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	replies := make(chan *sdk.Message, 1)
+	inbox, err := t.subscribe(t.bus.newInbox(), "", func(reply *sdk.Message) {
+		select {
+		case replies <- reply:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer inbox.Unsubscribe()
+
+	req := copyMessage(msg)
+	req.Reply = inbox.subject
+	t.countOut(req)
+	if t.bus.publish(req) == 0 {
+		return nil, ErrNoResponders
+	}
+
+	select {
+	case reply := <-replies:
+		return reply, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Respond 回复请求
+func (t *Transport) Respond(req *sdk.Message, resp *sdk.Message) error {
+	if req.Reply == "" {
+		return errors.New("sdktest: message has no reply subject")
+	}
+	reply := copyMessage(resp)
+	reply.Subject = req.Reply
+	return t.Publish(reply)
+}
+
+// IsConnected 检查连接状态
+func (t *Transport) IsConnected() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.connected
+}
+
+// SetReconnectHandler 设置重连成功后的回调
+func (t *Transport) SetReconnectHandler(handler func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reconnectHandler = handler
+}
+
+// SetConnStateHandler 设置连接状态变化回调
+func (t *Transport) SetConnStateHandler(handler func(state sdk.ConnState, err error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stateHandler = handler
+}
+
+// notifyState 通知连接状态变化
+func (t *Transport) notifyState(state sdk.ConnState, err error) {
+	t.mu.Lock()
+	handler := t.stateHandler
+	t.mu.Unlock()
+	if handler != nil {
+		handler(state, err)
+	}
+}
+
+// ConnState 当前连接状态
+func (t *Transport) ConnState() sdk.ConnState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case t.closed:
+		return sdk.ConnStateClosed
+	case t.connected:
+		return sdk.ConnStateConnected
+	default:
+		return sdk.ConnStateReconnecting
+	}
+}
+
+// Stats 连接统计
+func (t *Transport) Stats() sdk.ConnStats {
+	return sdk.ConnStats{
+		InMsgs:     t.inMsgs.Load(),
+		OutMsgs:    t.outMsgs.Load(),
+		InBytes:    t.inBytes.Load(),
+		OutBytes:   t.outBytes.Load(),
+		Reconnects: t.reconnects.Load(),
+	}
+}
+
+// Disconnect 模拟断开连接：断开期间发布失败，也收不到消息
+// 连接状态回调收到的断开原因为 ErrSimulatedDisconnect
+func (t *Transport) Disconnect() {
+	t.mu.Lock()
+	if t.closed || !t.connected {
+		t.mu.Unlock()
+		return
+	}
+	t.connected = false
+	t.mu.Unlock()
+
+	t.notifyState(sdk.ConnStateDisconnected, ErrSimulatedDisconnect)
+	t.notifyState(sdk.ConnStateReconnecting, nil)
+}
+
+// Reconnect 模拟重连成功，并调用重连回调
+func (t *Transport) Reconnect() {
+	t.mu.Lock()
+	if t.closed || t.connected {
+		t.mu.Unlock()
+		return
+	}
+	t.connected = true
+	handler := t.reconnectHandler
+	t.mu.Unlock()
+
+	t.reconnects.Add(1)
+	t.notifyState(sdk.ConnStateConnected, nil)
+	if handler != nil {
+		go handler()
+	}
+}
+
+// Close 关闭连接并取消所有订阅
+func (t *Transport) Close() {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return
+	}
+	t.closed = true
+	t.connected = false
+	subs := t.subs
+	t.subs = nil
+	t.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+	t.notifyState(sdk.ConnStateClosed, nil)
+}
+
+// subscription 总线上的订阅
+type subscription struct {
+	transport *Transport
+	subject   string
+	queue     string
+	handler   sdk.MessageHandler
+
+	mu      sync.Mutex
+	pending []*sdk.Message
+	closed  bool
+	notify  chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// deliver 将消息放入待处理队列
+func (s *subscription) deliver(msg *sdk.Message) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.pending = append(s.pending, msg)
+	s.mu.Unlock()
+
+	s.transport.inMsgs.Add(1)
+	s.transport.inBytes.Add(uint64(len(msg.Data)))
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run 按顺序处理消息
+func (s *subscription) run() {
+	for {
+		select {
+		case <-s.notify:
+		case <-s.done:
+			return
+		}
+
+		for {
+			s.mu.Lock()
+			if s.closed || len(s.pending) == 0 {
+				s.mu.Unlock()
+				break
+			}
+			msg := s.pending[0]
+			s.pending = s.pending[1:]
+			s.mu.Unlock()
+
+			s.handler(msg)
+		}
+	}
+}
+
+// Unsubscribe 取消订阅
+func (s *subscription) Unsubscribe() error {
+	s.once.Do(func() {
+		s.transport.bus.removeSubscription(s)
+		s.mu.Lock()
+		s.closed = true
+		s.pending = nil
+		s.mu.Unlock()
+		close(s.done)
+	})
+	return nil
+}
+
+// subjectMatches 判断主题是否匹配订阅（NATS 语法：* 匹配一段，> 匹配剩余所有段）
+func subjectMatches(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+
+	for i, token := range patternTokens {
+		if token == ">" {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) {
+			return false
+		}
+		if token != "*" && token != subjectTokens[i] {
+			return false
+		}
+	}
+
+	return len(patternTokens) == len(subjectTokens)
+}
+
+// copyMessage 复制消息，避免订阅者之间共享数据
+func copyMessage(msg *sdk.Message) *sdk.Message {
+	out := &sdk.Message{
+		Subject: msg.Subject,
+		Reply:   msg.Reply,
+		Data:    append([]byte(nil), msg.Data...),
+	}
+	if msg.Header != nil {
+		out.Header = make(sdk.Header, len(msg.Header))
+		for k, v := range msg.Header {
+			out.Header[k] = append([]string(nil), v...)
+		}
+	}
+	return out
+}
@@ -0,0 +1,165 @@
+package sdktest_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/punk-one/edge-app-sdk/sdk"
+	"github.com/punk-one/edge-app-sdk/sdk/sdktest"
+)
+
+// collector 记录收到的消息主题
+type collector struct {
+	mu       sync.Mutex
+	subjects []string
+}
+
+func (c *collector) handle(msg *sdk.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subjects = append(c.subjects, msg.Subject)
+}
+
+func (c *collector) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.subjects)
+}
+
+// waitLen 等待收到 n 条消息，再等待一小段时间确认没有多余的消息
+func (c *collector) waitLen(t *testing.T, name string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for c.len() < n && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := c.len(); got != n {
+		t.Errorf("%s received %d messages, want %d", name, got, n)
+	}
+}
+
+func TestBusWildcards(t *testing.T) {
+	bus := sdktest.NewBus()
+	sub, pub := bus.Connect(), bus.Connect()
+	defer sub.Close()
+	defer pub.Close()
+
+	var exact, star, tail collector
+	sub.Subscribe("app.camera.logs", exact.handle)
+	sub.Subscribe("app.*.logs", star.handle)
+	sub.Subscribe("app.>", tail.handle)
+
+	for _, subject := range []string{"app.camera.logs", "app.x.y.logs", "app.camera.events", "other.camera.logs"} {
+		if err := pub.Publish(&sdk.Message{Subject: subject}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exact.waitLen(t, "app.camera.logs", 1)
+	star.waitLen(t, "app.*.logs", 1)
+	tail.waitLen(t, "app.>", 3)
+}
+
+func TestBusQueueGroups(t *testing.T) {
+	bus := sdktest.NewBus()
+	conn := bus.Connect()
+	defer conn.Close()
+
+	var q1, q2, plain collector
+	conn.QueueSubscribe("jobs", "workers", q1.handle)
+	conn.QueueSubscribe("jobs", "workers", q2.handle)
+	conn.Subscribe("jobs", plain.handle)
+
+	const n = 10
+	for i := 0; i < n; i++ {
+		conn.Publish(&sdk.Message{Subject: "jobs"})
+	}
+
+	plain.waitLen(t, "plain subscriber", n)
+	if got := q1.len() + q2.len(); got != n {
+		t.Errorf("queue group received %d messages, want %d", got, n)
+	}
+	if q1.len() == 0 || q2.len() == 0 {
+		t.Errorf("queue group messages not distributed: %d, %d", q1.len(), q2.len())
+	}
+}
+
+func TestBusRequestReply(t *testing.T) {
+	bus := sdktest.NewBus()
+	server, client := bus.Connect(), bus.Connect()
+	defer server.Close()
+	defer client.Close()
+
+	server.Subscribe("echo", func(msg *sdk.Message) {
+		server.Respond(msg, &sdk.Message{Data: append([]byte("re: "), msg.Data...)})
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reply, err := client.Request(ctx, &sdk.Message{Subject: "echo", Data: []byte("hi")})
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	if string(reply.Data) != "re: hi" {
+		t.Errorf("reply = %q", reply.Data)
+	}
+
+	if _, err := client.Request(ctx, &sdk.Message{Subject: "nobody"}); !errors.Is(err, sdk.ErrNoResponders) {
+		t.Errorf("Request() without responders error = %v, want ErrNoResponders", err)
+	}
+}
+
+func TestBusDisconnect(t *testing.T) {
+	bus := sdktest.NewBus()
+	conn, pub := bus.Connect(), bus.Connect()
+	defer conn.Close()
+	defer pub.Close()
+
+	var mu sync.Mutex
+	var states []sdk.ConnState
+	conn.SetConnStateHandler(func(state sdk.ConnState, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		states = append(states, state)
+	})
+	reconnected := make(chan struct{})
+	conn.SetReconnectHandler(func() { close(reconnected) })
+
+	var got collector
+	conn.Subscribe("a", got.handle)
+
+	conn.Disconnect()
+	if err := conn.Publish(&sdk.Message{Subject: "a"}); !errors.Is(err, sdktest.ErrNotConnected) {
+		t.Errorf("Publish() while disconnected error = %v", err)
+	}
+	pub.Publish(&sdk.Message{Subject: "a"}) // 断开期间收不到消息
+
+	conn.Reconnect()
+	select {
+	case <-reconnected:
+	case <-time.After(time.Second):
+		t.Fatal("reconnect handler not called")
+	}
+	pub.Publish(&sdk.Message{Subject: "a"})
+	got.waitLen(t, "subscriber", 1)
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []sdk.ConnState{sdk.ConnStateDisconnected, sdk.ConnStateReconnecting, sdk.ConnStateConnected}
+	if len(states) != len(want) {
+		t.Fatalf("states = %v, want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Errorf("states = %v, want %v", states, want)
+			break
+		}
+	}
+	if conn.Stats().Reconnects != 1 {
+		t.Errorf("Reconnects = %d, want 1", conn.Stats().Reconnects)
+	}
+}
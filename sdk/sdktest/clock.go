@@ -0,0 +1,110 @@
+package sdktest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/punk-one/edge-app-sdk/sdk"
+)
+
+// FakeClock 可控时钟，实现 sdk.Clock。时间只在调用 Advance 时前进
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// NewFakeClock 创建可控时钟，起始时间为 start（为零值时使用当前时间）
+func NewFakeClock(start time.Time) *FakeClock {
+	if start.IsZero() {
+		start = time.Now()
+	}
+	c := &FakeClock{now: start}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now 当前时间
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTicker 创建定时器，只在 Advance 越过触发时间时触发
+func (c *FakeClock) NewTicker(d time.Duration) sdk.Ticker {
+	if d <= 0 {
+		panic("sdktest: non-positive interval for NewTicker")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTicker{
+		clock:    c,
+		ch:       make(chan time.Time, 1),
+		interval: d,
+		next:     c.now.Add(d),
+	}
+	c.tickers = append(c.tickers, t)
+	c.cond.Broadcast()
+	return t
+}
+
+// Advance 将时间前进 d，并触发到期的定时器
+// 与 time.Ticker 一样，接收方处理不及时的触发会被丢弃
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		for !t.next.After(c.now) {
+			select {
+			case t.ch <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.interval)
+		}
+	}
+}
+
+// WaitForTickers 等待至少 n 个定时器处于活动状态
+// 用于在 Advance 之前确认后台 goroutine（如心跳）已经创建了定时器
+func (c *FakeClock) WaitForTickers(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.tickers) < n {
+		c.cond.Wait()
+	}
+}
+
+// removeTicker 移除已停止的定时器
+func (c *FakeClock) removeTicker(t *fakeTicker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, ticker := range c.tickers {
+		if ticker == t {
+			c.tickers = append(c.tickers[:i], c.tickers[i+1:]...)
+			break
+		}
+	}
+	c.cond.Broadcast()
+}
+
+// fakeTicker FakeClock 创建的定时器
+type fakeTicker struct {
+	clock    *FakeClock
+	ch       chan time.Time
+	interval time.Duration
+	next     time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.removeTicker(t)
+}
@@ -0,0 +1,74 @@
+package sdktest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/punk-one/edge-app-sdk/sdk/sdktest"
+)
+
+func TestFakeClockAdvance(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := sdktest.NewFakeClock(start)
+	ticker := clock.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	clock.Advance(9 * time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatal("ticker fired before its interval")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case tick := <-ticker.C():
+		if !tick.Equal(start.Add(10 * time.Second)) {
+			t.Errorf("tick = %v, want %v", tick, start.Add(10*time.Second))
+		}
+	default:
+		t.Fatal("ticker did not fire")
+	}
+
+	// 与 time.Ticker 一样，未及时接收的触发被丢弃
+	clock.Advance(30 * time.Second)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Fatal("missed ticks were buffered")
+	default:
+	}
+	if got := clock.Now(); !got.Equal(start.Add(40 * time.Second)) {
+		t.Errorf("Now() = %v", got)
+	}
+}
+
+func TestFakeClockWaitForTickers(t *testing.T) {
+	clock := sdktest.NewFakeClock(time.Time{})
+	created := make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		clock.NewTicker(time.Second)
+		close(created)
+	}()
+
+	clock.WaitForTickers(1)
+	select {
+	case <-created:
+	case <-time.After(time.Second):
+		t.Fatal("WaitForTickers returned before the ticker was created")
+	}
+}
+
+func TestFakeClockStop(t *testing.T) {
+	clock := sdktest.NewFakeClock(time.Time{})
+	ticker := clock.NewTicker(time.Second)
+	ticker.Stop()
+
+	clock.Advance(time.Minute)
+	select {
+	case <-ticker.C():
+		t.Fatal("stopped ticker fired")
+	default:
+	}
+}
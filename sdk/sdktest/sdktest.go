@@ -0,0 +1,54 @@
+// Package sdktest 提供基于 SDK 开发的 App 的测试工具：
+// 进程内消息总线（Bus/Transport）、模拟 edge-agent（Agent）和可控时钟（FakeClock），
+// 无需运行 nats-server 即可测试命令、配置下发、心跳、日志、事件和状态上报。
+//
+//	func TestApp(t *testing.T) {
+//		clock := sdktest.NewFakeClock(time.Time{})
+//		client, agent := sdktest.New(t, sdk.Options{AppKey: "app.camera", Clock: clock})
+//		client.OnCommand(handleCommand)
+//
+//		result := agent.SendCommand(sdk.Command{Action: "snapshot"})
+//		if !result.Success {
+//			t.Fatal(result.Message)
+//		}
+//
+//		clock.WaitForTickers(1)
+//		clock.Advance(30 * time.Second)
+//		agent.AwaitHeartbeats(2)
+//	}
+package sdktest
+
+import (
+	"testing"
+
+	"github.com/punk-one/edge-app-sdk/sdk"
+)
+
+// New 创建通过进程内总线连接的 Client 和模拟 edge-agent，测试结束时自动关闭
+// 未指定时 AppKey 默认为 "app.test"，ConfigDir 使用测试临时目录，Transport 使用进程内总线
+func New(t testing.TB, opts sdk.Options) (*sdk.Client, *Agent) {
+	t.Helper()
+
+	if opts.AppKey == "" {
+		opts.AppKey = "app.test"
+	}
+	if opts.ConfigDir == "" {
+		opts.ConfigDir = t.TempDir()
+	}
+
+	bus := NewBus()
+	agent := NewAgent(t, bus, opts.AppKey)
+	t.Cleanup(agent.Close)
+
+	if opts.Transport == nil {
+		opts.Transport = bus.Connect()
+	}
+
+	client, err := sdk.NewClient(opts)
+	if err != nil {
+		t.Fatalf("sdktest: failed to create client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client, agent
+}
@@ -0,0 +1,84 @@
+package sdktest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/punk-one/edge-app-sdk/sdk"
+	"github.com/punk-one/edge-app-sdk/sdk/sdktest"
+)
+
+// README 中的测试示例：命令、配置下发、心跳和事件
+func TestReadmeRecipe(t *testing.T) {
+	clock := sdktest.NewFakeClock(time.Time{})
+	client, agent := sdktest.New(t, sdk.Options{
+		AppKey:            "app.camera",
+		HeartbeatInterval: 10 * time.Second,
+		Clock:             clock,
+	})
+	agent.SetTimeout(2 * time.Second)
+	client.OnCommand(func(cmd sdk.Command) sdk.CommandResult {
+		if cmd.Action != "snapshot" {
+			return sdk.CommandResult{Success: false, Message: "unknown action " + cmd.Action}
+		}
+		client.EmitEvent("snapshot.done", map[string]interface{}{"id": cmd.CommandID})
+		return sdk.CommandResult{Success: true, Message: "ok"}
+	})
+	var fps interface{}
+	client.OnConfig(func(cfg map[string]interface{}) error {
+		fps = cfg["fps"]
+		return nil
+	})
+
+	result := agent.SendCommand(sdk.Command{Action: "snapshot"})
+	if !result.Success || result.CommandID == "" {
+		t.Fatalf("SendCommand() = %+v", result)
+	}
+
+	ack := agent.SendConfig(sdk.ConfigData{Config: map[string]interface{}{"fps": 30}, Version: "2"})
+	if ack.Phase != sdk.ConfigPhaseApplied || !ack.Success || ack.Version != "2" || ack.CorrelationID == "" {
+		t.Fatalf("SendConfig() = %+v", ack)
+	}
+	if fps != float64(30) {
+		t.Errorf("config handler got fps = %v, want 30", fps)
+	}
+
+	clock.WaitForTickers(1)
+	clock.Advance(10 * time.Second)
+	heartbeats := agent.AwaitHeartbeats(2)
+	if heartbeats[0].AppKey != "app.camera" || heartbeats[1].Status != "running" {
+		t.Errorf("heartbeats = %+v", heartbeats)
+	}
+
+	event := agent.AwaitEvent("snapshot.done")
+	if event.Data["id"] != result.CommandID {
+		t.Errorf("event data = %v, want id %s", event.Data, result.CommandID)
+	}
+}
+
+// 包文档和 README 中的心跳用法：WaitForTickers(1) 返回后 Advance 一个心跳间隔必定触发心跳
+func TestHeartbeatRecipe(t *testing.T) {
+	tests := []struct {
+		name string
+		opts sdk.Options
+	}{
+		{"default", sdk.Options{}},
+		{"object ttl", sdk.Options{ObjectTTL: time.Hour}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				clock := sdktest.NewFakeClock(time.Time{})
+				opts := tt.opts
+				opts.AppKey = "app.camera"
+				opts.Clock = clock
+				_, agent := sdktest.New(t, opts)
+				agent.SetTimeout(2 * time.Second)
+
+				clock.WaitForTickers(1)
+				clock.Advance(30 * time.Second)
+				agent.AwaitHeartbeats(2)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package sdk
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// 配置中的密钥标记：
+//
+//	下发时：password: {"$secret": "明文"}
+//	落盘后：password: {"$encrypted": "v1:<base64(nonce|密文)>"}
+const (
+	secretMarker    = "$secret"
+	encryptedMarker = "$encrypted"
+	encryptedPrefix = "v1:"
+	redactedValue   = "******"
+)
+
+// 设备密钥相关环境变量
+const (
+	EnvSecretKey     = "EDGE_APP_SECRET_KEY"      // base64 编码的 32 字节密钥
+	EnvSecretKeyFile = "EDGE_APP_SECRET_KEY_FILE" // 密钥文件路径
+)
+
+// Secret 解密后的密钥值
+// 打印、JSON/YAML/MessagePack/CBOR 序列化时输出脱敏值，需要明文时调用 Value()
+type Secret string
+
+// Value 返回明文
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String 返回脱敏值，避免密钥出现在日志中
+func (s Secret) String() string {
+	return redactedValue
+}
+
+// GoString 返回脱敏值（%#v）
+func (s Secret) GoString() string {
+	return redactedValue
+}
+
+// MarshalJSON 序列化为脱敏值
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redactedValue + `"`), nil
+}
+
+// MarshalYAML 序列化为脱敏值
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return redactedValue, nil
+}
+
+// EncodeMsgpack MessagePack 序列化为脱敏值
+func (s Secret) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return enc.EncodeString(redactedValue)
+}
+
+// MarshalCBOR CBOR 序列化为脱敏值
+func (s Secret) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(redactedValue)
+}
+
+// secretBox 使用设备密钥进行 AES-256-GCM 加解密
+type secretBox struct {
+	keyFile string
+	mu      sync.Mutex
+	aead    cipher.AEAD // 加载成功后缓存；失败不缓存，下次使用时重试
+}
+
+// newSecretBox 创建加解密器，密钥在首次使用时加载
+func newSecretBox(keyFile string) *secretBox {
+	return &secretBox{keyFile: keyFile}
+}
+
+// load 加载设备密钥，优先使用环境变量，密钥文件不存在时自动生成
+func (b *secretBox) load() (cipher.AEAD, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.aead != nil {
+		return b.aead, nil
+	}
+	key, err := b.readKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %w", err)
+	}
+	b.aead = aead
+	return aead, nil
+}
+
+// readKey 读取或生成 32 字节密钥
+func (b *secretBox) readKey() ([]byte, error) {
+	if encoded := os.Getenv(EnvSecretKey); encoded != "" {
+		return decodeSecretKey(encoded)
+	}
+
+	data, err := os.ReadFile(b.keyFile)
+	if err == nil {
+		return decodeSecretKey(string(data))
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read secret key file: %w", err)
+	}
+
+	// 首次使用，生成新的设备密钥
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate secret key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(b.keyFile), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create secret key directory: %w", err)
+	}
+	created, err := createFileExclusive(b.keyFile, []byte(base64.StdEncoding.EncodeToString(key)), 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write secret key file: %w", err)
+	}
+	if !created {
+		// 共享 ConfigDir 的其他进程已生成密钥，使用对方的密钥，保证双方加密的配置都能解密
+		data, err := os.ReadFile(b.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret key file: %w", err)
+		}
+		return decodeSecretKey(string(data))
+	}
+
+	return key, nil
+}
+
+// decodeSecretKey 解码 base64 密钥
+func decodeSecretKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key encoding: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid secret key length: %d (want 32)", len(key))
+	}
+	return key, nil
+}
+
+// encrypt 加密明文
+func (b *secretBox) encrypt(plaintext string) (string, error) {
+	aead, err := b.load()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt 解密密文
+func (b *secretBox) decrypt(ciphertext string) (string, error) {
+	aead, err := b.load()
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(ciphertext, encryptedPrefix) {
+		return "", errors.New("unsupported secret encoding")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid secret encoding: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("secret ciphertext too short")
+	}
+
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// secretMarkerValue 判断是否为密钥标记，返回标记类型和值
+func secretMarkerValue(v interface{}) (string, string, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return "", "", false
+	}
+	for _, marker := range []string{secretMarker, encryptedMarker} {
+		if s, ok := m[marker].(string); ok {
+			return marker, s, true
+		}
+	}
+	return "", "", false
+}
+
+// transformSecrets 深拷贝配置，并对每个密钥值调用 fn 替换
+func transformSecrets(v interface{}, fn func(marker, value string) (interface{}, error)) (interface{}, error) {
+	if s, ok := v.(Secret); ok {
+		return fn(secretMarker, s.Value())
+	}
+	if marker, value, ok := secretMarkerValue(v); ok {
+		return fn(marker, value)
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			converted, err := transformSecrets(item, fn)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			out[k] = converted
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			converted, err := transformSecrets(item, fn)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out[i] = converted
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// transformConfig transformSecrets 的 map 版本
+func transformConfig(config map[string]interface{}, fn func(marker, value string) (interface{}, error)) (map[string]interface{}, error) {
+	out, err := transformSecrets(config, fn)
+	if err != nil {
+		return nil, err
+	}
+	return out.(map[string]interface{}), nil
+}
+
+// sealSecrets 将明文密钥加密为落盘格式
+func (b *secretBox) sealSecrets(config map[string]interface{}) (map[string]interface{}, error) {
+	return transformConfig(config, func(marker, value string) (interface{}, error) {
+		if marker == encryptedMarker {
+			return map[string]interface{}{encryptedMarker: value}, nil
+		}
+		encrypted, err := b.encrypt(value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{encryptedMarker: encrypted}, nil
+	})
+}
+
+// openSecrets 将密钥解密为 Secret 值
+func (b *secretBox) openSecrets(config map[string]interface{}) (map[string]interface{}, error) {
+	return transformConfig(config, func(marker, value string) (interface{}, error) {
+		if marker == secretMarker {
+			return Secret(value), nil
+		}
+		plaintext, err := b.decrypt(value)
+		if err != nil {
+			return nil, err
+		}
+		return Secret(plaintext), nil
+	})
+}
+
+// exportSecrets 将密钥还原为下发格式（{"$secret": 明文}），用于把配置同步回 edge-agent
+func (b *secretBox) exportSecrets(config map[string]interface{}) (map[string]interface{}, error) {
+	return transformConfig(config, func(marker, value string) (interface{}, error) {
+		if marker == encryptedMarker {
+			plaintext, err := b.decrypt(value)
+			if err != nil {
+				return nil, err
+			}
+			value = plaintext
+		}
+		return map[string]interface{}{secretMarker: value}, nil
+	})
+}
+
+// RedactConfig 返回脱敏后的配置副本，密钥值（无论明文、密文还是 Secret）均替换为 "******"
+func RedactConfig(config map[string]interface{}) map[string]interface{} {
+	redacted, _ := transformConfig(config, func(marker, value string) (interface{}, error) {
+		return redactedValue, nil
+	})
+	return redacted
+}
+
+// lookupConfigPath 按点分路径（如 "db.password"）查找配置值
+func lookupConfigPath(config map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = config
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// GetSecret 从配置文件中读取并解密指定路径（如 "db.password"）的密钥
+func (c *Client) GetSecret(path string) (string, error) {
+	config, err := c.LoadConfig()
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := lookupConfigPath(config, path)
+	if !ok {
+		return "", fmt.Errorf("config key not found: %s", path)
+	}
+	secret, ok := value.(Secret)
+	if !ok {
+		return "", fmt.Errorf("config key is not a secret: %s", path)
+	}
+
+	return secret.Value(), nil
+}
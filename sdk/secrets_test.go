@@ -0,0 +1,192 @@
+package sdk
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newTestSecretBox(t *testing.T) *secretBox {
+	t.Helper()
+	t.Setenv(EnvSecretKey, "")
+	return newSecretBox(filepath.Join(t.TempDir(), ".secret.key"))
+}
+
+func TestSecretBoxRoundTrip(t *testing.T) {
+	box := newTestSecretBox(t)
+
+	for _, plaintext := range []string{"", "p@ssw0rd", "密码", strings.Repeat("x", 4096)} {
+		encrypted, err := box.encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("encrypt(%q): %v", plaintext, err)
+		}
+		if !strings.HasPrefix(encrypted, encryptedPrefix) {
+			t.Fatalf("encrypt(%q) = %q, want prefix %q", plaintext, encrypted, encryptedPrefix)
+		}
+		if plaintext != "" && strings.Contains(encrypted, plaintext) {
+			t.Fatalf("encrypt(%q) leaks plaintext", plaintext)
+		}
+		decrypted, err := box.decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("decrypt(encrypt(%q)): %v", plaintext, err)
+		}
+		if decrypted != plaintext {
+			t.Fatalf("decrypt(encrypt(%q)) = %q", plaintext, decrypted)
+		}
+	}
+
+	// 每次加密使用随机 nonce
+	a, _ := box.encrypt("same")
+	b, _ := box.encrypt("same")
+	if a == b {
+		t.Fatal("encrypting the same plaintext twice produced the same ciphertext")
+	}
+
+	// 密钥已落盘，新的 secretBox 可以解密
+	reopened := newSecretBox(box.keyFile)
+	decrypted, err := reopened.decrypt(a)
+	if err != nil || decrypted != "same" {
+		t.Fatalf("decrypt with reloaded key = %q, %v", decrypted, err)
+	}
+}
+
+func TestSecretBoxTamper(t *testing.T) {
+	box := newTestSecretBox(t)
+	encrypted, err := box.encrypt("p@ssw0rd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, encryptedPrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flip := func(i int) string {
+		tampered := append([]byte(nil), sealed...)
+		tampered[i] ^= 0x01
+		return encryptedPrefix + base64.StdEncoding.EncodeToString(tampered)
+	}
+
+	other := newTestSecretBox(t)
+	otherEncrypted, err := other.encrypt("p@ssw0rd")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		ciphertext string
+	}{
+		{"nonce", flip(0)},
+		{"ciphertext", flip(len(sealed) / 2)},
+		{"tag", flip(len(sealed) - 1)},
+		{"truncated", encryptedPrefix + base64.StdEncoding.EncodeToString(sealed[:len(sealed)-1])},
+		{"too short", encryptedPrefix + base64.StdEncoding.EncodeToString(sealed[:4])},
+		{"bad base64", encryptedPrefix + "!!!"},
+		{"no prefix", strings.TrimPrefix(encrypted, encryptedPrefix)},
+		{"unknown version", "v2:" + strings.TrimPrefix(encrypted, encryptedPrefix)},
+		{"other key", otherEncrypted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if plaintext, err := box.decrypt(tt.ciphertext); err == nil {
+				t.Fatalf("decrypt succeeded with %q", plaintext)
+			}
+		})
+	}
+}
+
+func TestSecretBoxConcurrentKeyCreation(t *testing.T) {
+	t.Setenv(EnvSecretKey, "")
+	keyFile := filepath.Join(t.TempDir(), ".secret.key")
+
+	// 共享 ConfigDir 的多个实例同时生成密钥，最终必须使用同一个密钥
+	const n = 8
+	boxes := make([]*secretBox, n)
+	encrypted := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range boxes {
+		boxes[i] = newSecretBox(keyFile)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			encrypted[i], errs[i] = boxes[i].encrypt("shared")
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range boxes {
+		if errs[i] != nil {
+			t.Fatalf("box %d: encrypt: %v", i, errs[i])
+		}
+	}
+	reopened := newSecretBox(keyFile)
+	for i, ciphertext := range encrypted {
+		if plaintext, err := reopened.decrypt(ciphertext); err != nil || plaintext != "shared" {
+			t.Fatalf("ciphertext from box %d: decrypt = %q, %v", i, plaintext, err)
+		}
+	}
+}
+
+func TestSecretBoxRetriesAfterLoadError(t *testing.T) {
+	t.Setenv(EnvSecretKey, "")
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "config")
+	if err := os.WriteFile(blocker, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	box := newSecretBox(filepath.Join(blocker, ".secret.key"))
+
+	if _, err := box.encrypt("x"); err == nil {
+		t.Fatal("encrypt succeeded although the key directory cannot be created")
+	}
+
+	// 错误不被缓存，问题消除后可以继续使用
+	if err := os.Remove(blocker); err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := box.encrypt("x")
+	if err != nil {
+		t.Fatalf("encrypt after recovery: %v", err)
+	}
+	if plaintext, err := box.decrypt(encrypted); err != nil || plaintext != "x" {
+		t.Fatalf("decrypt = %q, %v", plaintext, err)
+	}
+}
+
+// 各编码序列化 Secret 时都输出脱敏值，不泄露明文
+func TestSecretRedactedByCodecs(t *testing.T) {
+	type config struct {
+		Password Secret `json:"password" msgpack:"password" cbor:"password"`
+	}
+	const plaintext = "s3cr3t-plaintext"
+
+	for _, codec := range []Codec{JSONCodec, MsgPackCodec, CBORCodec} {
+		t.Run(codec.ContentType(), func(t *testing.T) {
+			for _, v := range []interface{}{
+				config{Password: plaintext},
+				map[string]interface{}{"password": Secret(plaintext)},
+			} {
+				data, err := codec.Marshal(v)
+				if err != nil {
+					t.Fatalf("Marshal: %v", err)
+				}
+				if strings.Contains(string(data), plaintext) {
+					t.Fatalf("encoded %T leaks plaintext: %q", v, data)
+				}
+
+				var decoded map[string]interface{}
+				if err := codec.Unmarshal(data, &decoded); err != nil {
+					t.Fatalf("Unmarshal: %v", err)
+				}
+				if decoded["password"] != redactedValue {
+					t.Fatalf("password = %v, want %q", decoded["password"], redactedValue)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// StorageBackend 存储（KV、对象存储）的后端
+type StorageBackend string
+
+const (
+	StorageJetStream StorageBackend = "jetstream" // NATS JetStream，数据由 nats-server 持久化，多个 App 实例共享
+	StorageLocal     StorageBackend = "local"     // 本地文件（StorageDir），只在同一设备上共享同一 StorageDir 的进程之间共享
+)
+
+// NATSConnProvider 可选接口，传输层实现后 Client 可以使用 JetStream（KV、对象存储）
+type NATSConnProvider interface {
+	// Conn 底层 NATS 连接
+	Conn() *nats.Conn
+}
+
+// jetStreamCheckTimeout 检查 JetStream 是否可用的超时时间
+const jetStreamCheckTimeout = 2 * time.Second
+
+// invalidBucketChars JetStream bucket 名称中不允许的字符
+var invalidBucketChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// defaultBucketName 由 AppKey 生成 bucket 名称（如 app.camera → app_camera）
+func defaultBucketName(appKey string) string {
+	return invalidBucketChars.ReplaceAllString(appKey, "_")
+}
+
+// validateBucketName 检查 bucket 名称：只能包含字母、数字、下划线和连字符
+func validateBucketName(bucket string) error {
+	if bucket == "" || invalidBucketChars.MatchString(bucket) {
+		return fmt.Errorf("invalid bucket name: %q", bucket)
+	}
+	return nil
+}
+
+// jetStream 返回 JetStream 上下文；传输层不是 NATS、未连接或服务端未开启 JetStream 时返回错误
+func (c *Client) jetStream(ctx context.Context) (jetstream.JetStream, error) {
+	if c.opts.DisableJetStream {
+		return nil, errors.New("JetStream is disabled")
+	}
+	provider, ok := c.transport.(NATSConnProvider)
+	if !ok {
+		return nil, errors.New("transport does not support JetStream")
+	}
+	conn := provider.Conn()
+	if conn == nil || !conn.IsConnected() {
+		return nil, errors.New("not connected to NATS")
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, jetStreamCheckTimeout)
+	defer cancel()
+	if _, err := js.AccountInfo(ctx); err != nil {
+		return nil, fmt.Errorf("JetStream is not available: %w", err)
+	}
+	return js, nil
+}
+
+// matchKey 按 NATS 主题语法匹配点分隔的键（* 匹配一个 token，> 匹配剩余的一个或多个 token）
+func matchKey(pattern, key string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	keyTokens := strings.Split(key, ".")
+	for i, token := range patternTokens {
+		if token == ">" {
+			return len(keyTokens) > i
+		}
+		if i >= len(keyTokens) || (token != "*" && token != keyTokens[i]) {
+			return false
+		}
+	}
+	return len(patternTokens) == len(keyTokens)
+}
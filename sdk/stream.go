@@ -0,0 +1,346 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// OverflowPolicy 数据流订阅的缓冲区满时的处理策略
+type OverflowPolicy string
+
+const (
+	// OverflowBlock 暂停接收，直到缓冲区有空位；积压的消息由传输层缓冲
+	// （NATS 超过订阅的待处理限制后丢弃消息并报告慢消费者）
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest 丢弃缓冲区中最旧的消息，适合只关心最新数据的场景（如传感器读数）
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowDropNewest 丢弃新收到的消息
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+)
+
+// DefaultStreamBufferSize 数据流订阅默认的缓冲区大小
+const DefaultStreamBufferSize = 256
+
+// StreamOptions 数据流订阅选项
+type StreamOptions struct {
+	Queue       string         // 队列组，同一队列组的订阅者（可以在不同 App 中）分担消息；为空时每个订阅者都收到所有消息
+	BufferSize  int            // 等待处理的消息缓冲区大小，默认 DefaultStreamBufferSize
+	Overflow    OverflowPolicy // 缓冲区满时的处理策略，默认 OverflowBlock
+	Concurrency int            // 并发处理消息的 goroutine 数，默认 1（按到达顺序处理）
+}
+
+// Stream 声明的输出数据流，发布到 app.<app_key>.data.<name>
+type Stream struct {
+	client  *Client
+	name    string
+	subject string
+}
+
+// validateStreamName 检查数据流名称：以点分隔的非空 token，不含通配符和空白字符
+func validateStreamName(name string, wildcards bool) error {
+	if name == "" {
+		return fmt.Errorf("stream name is required")
+	}
+	for _, token := range strings.Split(name, ".") {
+		if token == "" || strings.ContainsAny(token, " \t\r\n") {
+			return fmt.Errorf("invalid stream name: %q", name)
+		}
+		if !wildcards && (token == "*" || token == ">") {
+			return fmt.Errorf("stream name must not contain wildcards: %q", name)
+		}
+	}
+	return nil
+}
+
+// Stream 声明输出数据流，重复声明返回同一个 Stream
+// 名称为点分隔的 token（如 "frames"、"frames.meta"），声明的数据流通过心跳的 streams 字段告知 edge-agent 和其他 App
+func (c *Client) Stream(name string) (*Stream, error) {
+	if err := validateStreamName(name, false); err != nil {
+		return nil, err
+	}
+
+	c.streamsMu.Lock()
+	defer c.streamsMu.Unlock()
+	if s, ok := c.streams[name]; ok {
+		return s, nil
+	}
+	s := &Stream{client: c, name: name, subject: c.topics.Data(name)}
+	c.streams[name] = s
+	return s, nil
+}
+
+// Streams 返回声明的输出数据流名称，按名称排序
+func (c *Client) Streams() []string {
+	c.streamsMu.Lock()
+	defer c.streamsMu.Unlock()
+
+	names := make([]string, 0, len(c.streams))
+	for name := range c.streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Name 数据流名称
+func (s *Stream) Name() string {
+	return s.name
+}
+
+// Subject 数据流主题
+func (s *Stream) Subject() string {
+	return s.subject
+}
+
+// Publish 发布数据，使用 Options.Codec 编码，ctx 中的追踪上下文写入消息头
+func (s *Stream) Publish(ctx context.Context, data interface{}) error {
+	c := s.client
+	msg, err := c.encodeTypedMessage(ctx, c.codec, c.ProtocolVersion(), MessageTypeData, data)
+	if err != nil {
+		return err
+	}
+	msg.Subject = s.subject
+	if err := c.transport.Publish(msg); err != nil {
+		return fmt.Errorf("failed to publish to stream %s: %w", s.name, err)
+	}
+	return nil
+}
+
+// StreamMessage 数据流消息
+type StreamMessage struct {
+	AppKey   string   // 发布方 App 标识（取自 Edge-App-Key 消息头，没有时从主题解析）
+	Stream   string   // 数据流名称
+	Metadata Metadata // 消息头元数据，Metadata.Context() 为处理该消息的 span 所在的 context
+	Message  *Message // 原始消息
+
+	client *Client
+}
+
+// Decode 按消息头中的编码和协议版本解码数据
+func (m *StreamMessage) Decode(v interface{}) error {
+	return m.client.decodeMessage(m.Message, v)
+}
+
+// StreamHandler 数据流消息处理函数
+type StreamHandler func(msg *StreamMessage)
+
+// StreamSubscription 数据流订阅
+type StreamSubscription struct {
+	client  *Client
+	appKey  string
+	stream  string
+	opts    StreamOptions
+	handler StreamHandler
+	sub     Subscription
+
+	buffer  chan *Message
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Uint64
+}
+
+// SubscribeStream 订阅其他 App 的数据流
+// appKey 和 stream 可以使用 NATS 通配符（如 appKey 为 "*" 订阅所有 App 的同名数据流，stream 为 ">" 订阅一个 App 的所有数据流）；
+// 消息先放入缓冲区，再由 Concurrency 个 goroutine 调用 handler，缓冲区满时按 Overflow 处理
+func (c *Client) SubscribeStream(appKey, stream string, handler StreamHandler, opts ...StreamOptions) (*StreamSubscription, error) {
+	if appKey == "" {
+		return nil, fmt.Errorf("app key is required")
+	}
+	if err := validateStreamName(stream, true); err != nil {
+		return nil, err
+	}
+
+	o := StreamOptions{}
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = DefaultStreamBufferSize
+	}
+	if o.Overflow == "" {
+		o.Overflow = OverflowBlock
+	}
+	switch o.Overflow {
+	case OverflowBlock, OverflowDropOldest, OverflowDropNewest:
+	default:
+		return nil, fmt.Errorf("unsupported overflow policy: %s", o.Overflow)
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+
+	s := &StreamSubscription{
+		client:  c,
+		appKey:  appKey,
+		stream:  stream,
+		opts:    o,
+		handler: handler,
+		buffer:  make(chan *Message, o.BufferSize),
+		done:    make(chan struct{}),
+	}
+
+	subject := NewTopicBuilder(appKey).Data(stream)
+	var err error
+	if o.Queue != "" {
+		s.sub, err = c.transport.QueueSubscribe(subject, o.Queue, s.enqueue)
+	} else {
+		s.sub, err = c.transport.Subscribe(subject, s.enqueue)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to stream %s: %w", subject, err)
+	}
+
+	for i := 0; i < o.Concurrency; i++ {
+		go s.run()
+	}
+
+	c.streamsMu.Lock()
+	c.streamSubs[s] = struct{}{}
+	c.streamsMu.Unlock()
+	return s, nil
+}
+
+// SubscribeStreamAs 订阅其他 App 的数据流，并将数据解码为 T；无法解码的消息记录警告后跳过
+func SubscribeStreamAs[T any](c *Client, appKey, stream string, handler func(data T, msg *StreamMessage), opts ...StreamOptions) (*StreamSubscription, error) {
+	return c.SubscribeStream(appKey, stream, func(msg *StreamMessage) {
+		var data T
+		if err := msg.Decode(&data); err != nil {
+			c.logger.Warnf("Failed to decode message from stream %s of %s: %v", msg.Stream, msg.AppKey, err)
+			return
+		}
+		handler(data, msg)
+	}, opts...)
+}
+
+// enqueue 传输层回调，按 Overflow 将消息放入缓冲区
+func (s *StreamSubscription) enqueue(msg *Message) {
+	switch s.opts.Overflow {
+	case OverflowDropNewest:
+		select {
+		case s.buffer <- msg:
+		case <-s.done:
+		default:
+			s.dropped.Add(1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case s.buffer <- msg:
+				return
+			case <-s.done:
+				return
+			default:
+			}
+			select {
+			case <-s.buffer:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case s.buffer <- msg:
+		case <-s.done:
+		}
+	}
+}
+
+// run 从缓冲区取出消息并处理，取消订阅后退出
+func (s *StreamSubscription) run() {
+	for {
+		select {
+		case msg := <-s.buffer:
+			s.handle(msg)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// handle 在 span 中调用处理函数
+func (s *StreamSubscription) handle(msg *Message) {
+	c := s.client
+	meta := MetadataFromHeader(msg.Header)
+	stream := s.stream
+	if meta.AppKey != "" {
+		if name, ok := strings.CutPrefix(msg.Subject, NewTopicBuilder(meta.AppKey).Data("")); ok {
+			stream = name
+		}
+	} else if appKey, name, ok := parseStreamSubject(s.appKey, msg.Subject); ok {
+		// 没有 Edge-App-Key 消息头（MQTT 3.1.1、旧版本或非 SDK 的发布方）时从主题解析
+		meta.AppKey = appKey
+		stream = name
+	}
+
+	ctx, span := c.startMessageSpan(msg, "stream "+stream)
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("edge.stream.name", stream),
+		attribute.String("edge.stream.app_key", meta.AppKey),
+	)
+	meta.ctx = ctx
+
+	s.handler(&StreamMessage{
+		AppKey:   meta.AppKey,
+		Stream:   stream,
+		Metadata: meta,
+		Message:  msg,
+		client:   c,
+	})
+}
+
+// parseStreamSubject 按订阅的 appKey 从主题 app.<app_key>.data.<stream> 中解析发布方 App 标识和数据流名称
+// appKey 中的通配符 * 只匹配一个 token，因此 App 标识占用的 token 数与订阅的 appKey 相同，
+// 点分隔的 App 标识（如 "app.camera"）也能准确解析
+func parseStreamSubject(appKey, subject string) (string, string, bool) {
+	n := len(strings.Split(appKey, "."))
+	tokens := strings.Split(subject, ".")
+	if len(tokens) < n+3 || tokens[0] != "app" || tokens[n+1] != "data" {
+		return "", "", false
+	}
+	return strings.Join(tokens[1:n+1], "."), strings.Join(tokens[n+2:], "."), true
+}
+
+// Pending 缓冲区中等待处理的消息数
+func (s *StreamSubscription) Pending() int {
+	return len(s.buffer)
+}
+
+// Dropped 因缓冲区满被丢弃的消息数（OverflowDropOldest、OverflowDropNewest）
+func (s *StreamSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe 取消订阅，缓冲区中未处理的消息被丢弃；正在执行的处理函数不受影响
+func (s *StreamSubscription) Unsubscribe() error {
+	var err error
+	s.once.Do(func() {
+		err = s.sub.Unsubscribe()
+		close(s.done)
+
+		s.client.streamsMu.Lock()
+		delete(s.client.streamSubs, s)
+		s.client.streamsMu.Unlock()
+	})
+	return err
+}
+
+// closeStreams 停止所有数据流订阅
+func (c *Client) closeStreams() {
+	c.streamsMu.Lock()
+	subs := make([]*StreamSubscription, 0, len(c.streamSubs))
+	for s := range c.streamSubs {
+		subs = append(subs, s)
+	}
+	c.streamsMu.Unlock()
+
+	for _, s := range subs {
+		s.Unsubscribe()
+	}
+}
@@ -0,0 +1,195 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceExporter 追踪数据导出方式
+type TraceExporter string
+
+const (
+	TraceExporterNone TraceExporter = ""     // 不导出，使用 TracerProvider 或全局 TracerProvider
+	TraceExporterOTLP TraceExporter = "otlp" // 通过 OTLP/HTTP 导出到 Collector
+	TraceExporterFile TraceExporter = "file" // 导出到本地文件（每行一个 JSON 格式的 span）
+)
+
+// tracerName SDK 创建 span 使用的 instrumentation 名称
+const tracerName = "github.com/punk-one/edge-app-sdk/sdk"
+
+// TracingOptions OpenTelemetry 追踪配置
+type TracingOptions struct {
+	Exporter    TraceExporter     // 导出方式，为空时不创建 TracerProvider
+	Endpoint    string            // OTLP/HTTP 地址，如 "localhost:4318" 或 "https://collector:4318/v1/traces"，为空时使用 OTEL_EXPORTER_OTLP_* 环境变量
+	Insecure    bool              // OTLP 使用 HTTP 而不是 HTTPS
+	Headers     map[string]string // OTLP 请求头（如认证信息）
+	File        string            // 文件导出路径，Exporter 为 file 时必填
+	SampleRatio float64           // 新追踪的采样比例（0-1），默认 1；上游已采样的追踪始终记录
+
+	// TracerProvider 自定义 TracerProvider，设置后忽略上面的导出配置，由调用方负责关闭
+	// 未设置且 Exporter 为空时使用 otel.GetTracerProvider()
+	TracerProvider trace.TracerProvider
+	// Propagator 消息头中追踪上下文的格式，默认 W3C Trace Context 和 Baggage
+	Propagator propagation.TextMapPropagator
+}
+
+// initTracing 创建 tracer，Exporter 不为空时创建并持有 TracerProvider（Close 时关闭）
+func (c *Client) initTracing() error {
+	opts := c.opts.Tracing
+
+	c.propagator = opts.Propagator
+	if c.propagator == nil {
+		c.propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
+
+	provider := opts.TracerProvider
+	if provider == nil && opts.Exporter != TraceExporterNone {
+		tp, err := c.newTracerProvider(opts)
+		if err != nil {
+			return err
+		}
+		c.tracerProvider = tp
+		provider = tp
+	}
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	c.tracer = provider.Tracer(tracerName, trace.WithInstrumentationVersion(c.opts.AppVersion))
+	return nil
+}
+
+// newTracerProvider 根据导出配置创建 TracerProvider
+func (c *Client) newTracerProvider(opts TracingOptions) (*sdktrace.TracerProvider, error) {
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case TraceExporterOTLP:
+		var options []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			if strings.Contains(opts.Endpoint, "://") {
+				options = append(options, otlptracehttp.WithEndpointURL(opts.Endpoint))
+			} else {
+				options = append(options, otlptracehttp.WithEndpoint(opts.Endpoint))
+			}
+		}
+		if opts.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		if len(opts.Headers) > 0 {
+			options = append(options, otlptracehttp.WithHeaders(opts.Headers))
+		}
+		exp, err := otlptracehttp.New(context.Background(), options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		exporter = exp
+	case TraceExporterFile:
+		if opts.File == "" {
+			return nil, errors.New("trace file is required for file exporter")
+		}
+		file, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to create file exporter: %w", err)
+		}
+		exporter = &fileExporter{SpanExporter: exp, file: file}
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter: %s", opts.Exporter)
+	}
+
+	ratio := opts.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", c.opts.AppKey),
+		attribute.String("service.version", c.opts.AppVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	), nil
+}
+
+// shutdownTracing 导出剩余的 span 并关闭 SDK 创建的 TracerProvider
+func (c *Client) shutdownTracing() {
+	if c.tracerProvider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.tracerProvider.Shutdown(ctx); err != nil {
+		c.logger.Errorf("Failed to shutdown tracer provider: %v", err)
+	}
+}
+
+// fileExporter 关闭时一并关闭文件的导出器
+type fileExporter struct {
+	sdktrace.SpanExporter
+	file *os.File
+}
+
+// Shutdown 关闭导出器和文件
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	if closeErr := e.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Tracer 返回 SDK 使用的 tracer，App 可用它创建与 SDK span 关联的子 span
+func (c *Client) Tracer() trace.Tracer {
+	return c.tracer
+}
+
+// startMessageSpan 从消息头提取追踪上下文，开始处理该消息的 span
+func (c *Client) startMessageSpan(msg *Message, name string) (context.Context, trace.Span) {
+	ctx := c.propagator.Extract(context.Background(), msg.Header)
+
+	kind := trace.SpanKindConsumer
+	if msg.Reply != "" {
+		kind = trace.SpanKindServer
+	}
+	return c.tracer.Start(ctx, name,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "nats"),
+			attribute.String("messaging.destination.name", msg.Subject),
+			attribute.String("edge.app.key", c.opts.AppKey),
+		),
+	)
+}
+
+// endSpan 根据错误设置 span 状态并结束 span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,178 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Header 消息头（与 NATS 消息头语义一致，键区分大小写）
+type Header map[string][]string
+
+// Get 获取指定键的第一个值
+func (h Header) Get(key string) string {
+	if values := h[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Values 获取指定键的所有值
+func (h Header) Values(key string) []string {
+	return h[key]
+}
+
+// Set 设置指定键的值（覆盖已有值）
+func (h Header) Set(key, value string) {
+	h[key] = []string{value}
+}
+
+// Add 为指定键追加值
+func (h Header) Add(key, value string) {
+	h[key] = append(h[key], value)
+}
+
+// Del 删除指定键
+func (h Header) Del(key string) {
+	delete(h, key)
+}
+
+// Keys 返回所有键（与 Get/Set 一起实现 OpenTelemetry 的 propagation.TextMapCarrier）
+func (h Header) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Message 传输层消息
+type Message struct {
+	Subject string // 主题（NATS 语法，点分隔）
+	Reply   string // 回复主题，不为空表示该消息是一个请求
+	Header  Header
+	Data    []byte
+}
+
+// MessageHandler 消息处理函数
+type MessageHandler func(msg *Message)
+
+// Subscription 订阅
+type Subscription interface {
+	Unsubscribe() error
+}
+
+// Transport 消息传输接口
+// 主题使用 NATS 语法（点分隔，支持 * 和 > 通配符），由具体实现负责映射
+type Transport interface {
+	// Publish 发布消息
+	Publish(msg *Message) error
+	// Subscribe 订阅主题
+	Subscribe(subject string, handler MessageHandler) (Subscription, error)
+	// QueueSubscribe 队列订阅，同一队列中只有一个订阅者收到消息
+	QueueSubscribe(subject, queue string, handler MessageHandler) (Subscription, error)
+	// Request 发送请求并等待回复（RPC），ctx 结束时返回 ctx.Err()
+	// 能够判断主题上没有订阅者的实现应立即返回 ErrNoResponders（可包装）
+	Request(ctx context.Context, msg *Message) (*Message, error)
+	// Respond 回复请求（RPC 回复）
+	Respond(req *Message, resp *Message) error
+	// IsConnected 检查连接状态
+	IsConnected() bool
+	// SetReconnectHandler 设置重连成功后的回调
+	// 传输层实现了 ConnStateReporter 时 Client 通过连接状态变化感知重连，不会调用此方法，回调留给 App 使用
+	SetReconnectHandler(handler func())
+	// Close 关闭连接
+	Close()
+}
+
+// publish 序列化并发布消息，ctx 中的追踪上下文写入消息头
+func (c *Client) publish(ctx context.Context, subject string, data interface{}) error {
+	msg, err := c.encodeMessage(ctx, c.codec, c.ProtocolVersion(), data)
+	if err != nil {
+		return err
+	}
+	msg.Subject = subject
+
+	return c.transport.Publish(msg)
+}
+
+// request 序列化并发送请求（RPC），通过 Accept 头告知对方期望的回复编码
+func (c *Client) request(ctx context.Context, subject string, data interface{}, timeout time.Duration) (*Message, error) {
+	return c.requestVersion(ctx, c.ProtocolVersion(), subject, data, timeout)
+}
+
+// requestVersion 使用指定协议版本发送请求
+func (c *Client) requestVersion(ctx context.Context, version int, subject string, data interface{}, timeout time.Duration) (*Message, error) {
+	msg, err := c.encodeMessage(ctx, c.codec, version, data)
+	if err != nil {
+		return nil, err
+	}
+	msg.Subject = subject
+	msg.Header.Set(HeaderAccept, c.codec.ContentType())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return c.transport.Request(ctx, msg)
+}
+
+// respond 序列化并回复请求（RPC 回复）
+// 回复编码依次取请求的 Accept、请求的 Content-Type，均不支持时使用 Options.Codec；协议版本与请求相同
+func (c *Client) respond(ctx context.Context, req *Message, data interface{}) error {
+	msg, err := c.encodeMessage(ctx, c.replyCodec(req), c.replyProtocol(req), data)
+	if err != nil {
+		return err
+	}
+
+	return c.transport.Respond(req, msg)
+}
+
+// encodeMessage 按指定编码和协议版本序列化消息体，设置 Content-Type 和标准消息头
+func (c *Client) encodeMessage(ctx context.Context, codec Codec, version int, data interface{}) (*Message, error) {
+	return c.encodeTypedMessage(ctx, codec, version, messageTypeOf(data), data)
+}
+
+// encodeTypedMessage 与 encodeMessage 相同，消息类型由调用方指定（无法从数据模型推断时，如数据流）
+func (c *Client) encodeTypedMessage(ctx context.Context, codec Codec, version int, msgType string, data interface{}) (*Message, error) {
+	header := Header{}
+	header.Set(HeaderContentType, codec.ContentType())
+	c.setStandardHeaders(ctx, header, msgType, version)
+
+	body := data
+	if version == ProtocolV2 {
+		body = Envelope{
+			Version:   version,
+			Type:      header.Get(HeaderMessageType),
+			ID:        header.Get(HeaderMessageID),
+			Timestamp: c.now().UnixMilli(),
+			Data:      data,
+		}
+	}
+	payload, err := codec.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	msg := &Message{Header: header, Data: payload}
+	if c.opts.ValidateMessages {
+		c.validateMessage("outgoing", msg, data)
+	}
+	return msg, nil
+}
+
+// replyCodec 选择回复请求使用的编码
+func (c *Client) replyCodec(req *Message) Codec {
+	for _, key := range []string{HeaderAccept, HeaderContentType} {
+		if contentType := req.Header.Get(key); contentType != "" {
+			if codec, ok := CodecFor(contentType); ok {
+				return codec
+			}
+		}
+	}
+	return c.codec
+}
+
+// subscribe 订阅主题
+func (c *Client) subscribe(subject string, handler MessageHandler) (Subscription, error) {
+	return c.transport.Subscribe(subject, handler)
+}